@@ -1,8 +1,11 @@
 package actions
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/sleep2death/hexcore/store"
 )
 
 var (
@@ -29,6 +32,8 @@ type Context struct {
 	outc chan<- []byte
 	// context id, which can be used for finding the certain store
 	id int
+	// values of the chain, see Value
+	values map[interface{}]interface{}
 }
 
 // NewContext -
@@ -55,11 +60,42 @@ func (c *Context) ID() int {
 	return c.id
 }
 
+// Value of the key, which is set by the previous actions of the chain,
+// nil if it's not set
+func (c *Context) Value(key interface{}) interface{} {
+	return c.values[key]
+}
+
+// SetValue of the key, it lives as long as the chain,
+// use an unexported type as the key to avoid collisions, like context.Context
+func (c *Context) SetValue(key, value interface{}) {
+	if c.values == nil {
+		c.values = make(map[interface{}]interface{})
+	}
+	c.values[key] = value
+}
+
 // Action -
 type Action interface {
 	Exec(ctx *Context) ([]Action, error)
 }
 
+// ActionFunc is an adapter to allow the use of ordinary functions as actions
+type ActionFunc func(ctx *Context) ([]Action, error)
+
+// Exec calls f(ctx)
+func (f ActionFunc) Exec(ctx *Context) ([]Action, error) {
+	return f(ctx)
+}
+
+// Undoable is implemented by the player input actions which can be undone,
+// the state will be marked before the action is executed, see Undo.
+// The checkpoints are sealed before the other inputs, see store.State.Seal
+type Undoable interface {
+	Action
+	Undoable() bool
+}
+
 // Timeout duration
 var timeout = time.Second * 5
 
@@ -76,6 +112,17 @@ func (a *WaitForInput) Exec(ctx *Context) ([]Action, error) {
 		if action == nil {
 			return nil, ErrCanceled
 		}
+
+		marked := false
+		if state := store.GetStore().State(ctx.ID()); state != nil {
+			if u, ok := action.(Undoable); ok && u.Undoable() {
+				state.Mark()
+				marked = true
+			} else if _, ok := action.(*Undo); !ok {
+				state.Seal()
+			}
+		}
+		ctx.SetValue(markKey{}, marked)
 		return []Action{action}, nil
 	case <-time.After(timeout): // timeout
 		return nil, ErrTimeout
@@ -97,9 +144,34 @@ func (a *OutputString) Exec(ctx *Context) ([]Action, error) {
 	}
 }
 
+// Emit action sends the event to the output as json,
+// e.g. {"event":"relic","data":{"id":"burning_blood"}}
+type Emit struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// Exec -
+func (a *Emit) Exec(ctx *Context) ([]Action, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return []Action{&OutputString{Message: string(data)}}, nil
+}
+
 // // Exec -
 // func (a *PlayCard) Exec(ctx *Context) ([]Action, error) {
 // 	state := store.GetStore().State(ctx.ID())
 // 	card, _, _ := state.GetPile(store.Hand).FindCard(a.ID)
 // 	action := GetActionByCardName(card.Name())
 // }
+
+// Reject the input action, the reason is sent to the output,
+// and the chain keeps waiting for the next input
+func Reject(action string, err error) []Action {
+	return []Action{&Emit{
+		Event: "rejected",
+		Data:  map[string]string{"action": action, "error": err.Error()},
+	}}
+}
@@ -0,0 +1,21 @@
+package actions
+
+import (
+	"github.com/sleep2death/hexcore/store"
+)
+
+// Checkpoint action saves the state of the context to the store backend,
+// add it into the chain at the turn boundaries,
+// so the game can be resumed from there after the server restarted.
+// It does nothing if the backend is not set.
+type Checkpoint struct {
+}
+
+// Exec -
+func (a *Checkpoint) Exec(ctx *Context) ([]Action, error) {
+	err := store.GetStore().Checkpoint(ctx.ID())
+	if err == store.ErrNoBackend {
+		return nil, nil
+	}
+	return nil, err
+}
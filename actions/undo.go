@@ -0,0 +1,41 @@
+package actions
+
+import (
+	"github.com/sleep2death/hexcore/store"
+)
+
+// key of the mark of the current input in the chain context
+type markKey struct{}
+
+// Unmark drops the undo checkpoint taken for the current input, if there is one,
+// call it when the input is rejected, so undo won't revert to it
+func Unmark(ctx *Context) {
+	if marked, _ := ctx.Value(markKey{}).(bool); marked {
+		store.GetStore().State(ctx.ID()).Unmark()
+		ctx.SetValue(markKey{}, false)
+	}
+}
+
+// Undo input action reverts the most recent undoable input of the turn,
+// if it's rejected, the reason will be sent to the output
+type Undo struct {
+}
+
+// Exec -
+func (a *Undo) Exec(ctx *Context) ([]Action, error) {
+	if err := store.GetStore().State(ctx.ID()).Undo(); err != nil {
+		return []Action{&OutputString{Message: "undo rejected: " + err.Error()}}, nil
+	}
+	return []Action{&OutputString{Message: "undo"}}, nil
+}
+
+// EndTurn action drops all the undo checkpoints of the turn,
+// add it into the chain when the player's turn is over
+type EndTurn struct {
+}
+
+// Exec -
+func (a *EndTurn) Exec(ctx *Context) ([]Action, error) {
+	store.GetStore().State(ctx.ID()).EndTurn()
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type increase struct {
+	undoable bool
+}
+
+func (a *increase) Exec(ctx *Context) ([]Action, error) {
+	state := store.GetStore().State(ctx.ID())
+	state.SetNum(state.Num() + 1)
+	return nil, nil
+}
+
+func (a *increase) Undoable() bool {
+	return a.undoable
+}
+
+func TestUndo(t *testing.T) {
+	state := &store.State{}
+	in := make(chan Action)
+	out := make(chan []byte)
+	ctx := NewContext(in, out, store.GetStore().AddState(state))
+
+	// read one input action, and execute it
+	input := func(a Action) []Action {
+		go func() { in <- a }()
+
+		next, err := (&WaitForInput{}).Exec(ctx)
+		assert.Nil(t, err)
+
+		res, err := next[0].Exec(ctx)
+		assert.Nil(t, err)
+		return res
+	}
+
+	// read the output of the undo action
+	output := func(next []Action) string {
+		go func() { next[0].Exec(ctx) }()
+		return string(<-out)
+	}
+
+	input(&increase{undoable: true})
+	input(&increase{undoable: true})
+	assert.Equal(t, 2, state.Num())
+
+	assert.Equal(t, "undo", output(input(&Undo{})))
+	assert.Equal(t, 1, state.Num())
+	assert.Equal(t, "undo", output(input(&Undo{})))
+	assert.Equal(t, 0, state.Num())
+
+	assert.Equal(t, "undo rejected: "+store.ErrNothingToUndo.Error(), output(input(&Undo{})))
+
+	// the input which can't be undone drops the checkpoints before it
+	input(&increase{undoable: true})
+	input(&increase{undoable: false})
+	assert.Equal(t, "undo rejected: "+store.ErrNothingToUndo.Error(), output(input(&Undo{})))
+	assert.Equal(t, 2, state.Num())
+
+	input(&increase{undoable: true})
+	input(&EndTurn{})
+	assert.Equal(t, "undo rejected: "+store.ErrNothingToUndo.Error(), output(input(&Undo{})))
+	assert.Equal(t, 3, state.Num())
+}
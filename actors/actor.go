@@ -1,10 +1,18 @@
 package actors
 
+import "github.com/sleep2death/hexcore/hex"
+
 // Actor of the battle
 type Actor struct {
 	id    string
 	HP    uint
 	MaxHP uint
+	// Block absorbs the damage before HP
+	Block uint
+
+	// position on the hex board, if it's placed
+	pos    hex.Coord
+	placed bool
 }
 
 // ID of the actor
@@ -12,12 +20,153 @@ func (a *Actor) ID() string {
 	return a.id
 }
 
+// SetID of the actor
+func (a *Actor) SetID(id string) {
+	a.id = id
+}
+
+// Position of the actor on the hex board, false if it's not placed
+func (a *Actor) Position() (hex.Coord, bool) {
+	return a.pos, a.placed
+}
+
+// Place the actor on the hex
+func (a *Actor) Place(c hex.Coord) {
+	a.pos = c
+	a.placed = true
+}
+
+// Unplace removes the actor from the hex board
+func (a *Actor) Unplace() {
+	a.pos = hex.Coord{}
+	a.placed = false
+}
+
+// Data is the serializable form of an actor
+type Data struct {
+	ID    string     `json:"id"`
+	HP    uint       `json:"hp"`
+	MaxHP uint       `json:"max_hp"`
+	Block uint       `json:"block,omitempty"`
+	Pos   *hex.Coord `json:"pos,omitempty"`
+	// Class and Energy of the player
+	Class  string `json:"class,omitempty"`
+	Energy int    `json:"energy,omitempty"`
+	// Kind and Intent of the monster
+	Kind   string `json:"kind,omitempty"`
+	Intent int    `json:"intent,omitempty"`
+}
+
+// Marshal the actor into data
+func (a *Actor) Marshal() Data {
+	d := Data{
+		ID:    a.id,
+		HP:    a.HP,
+		MaxHP: a.MaxHP,
+		Block: a.Block,
+	}
+	if a.placed {
+		pos := a.pos
+		d.Pos = &pos
+	}
+	return d
+}
+
+// Unmarshal the actor from data
+func (a *Actor) Unmarshal(d Data) {
+	a.id = d.ID
+	a.HP = d.HP
+	a.MaxHP = d.MaxHP
+	a.Block = d.Block
+	if d.Pos != nil {
+		a.Place(*d.Pos)
+	} else {
+		a.Unplace()
+	}
+}
+
+// Damage the actor, block absorbs it first, returns the HP lost
+func (a *Actor) Damage(amount uint) uint {
+	if a.Block >= amount {
+		a.Block -= amount
+		return 0
+	}
+
+	amount -= a.Block
+	a.Block = 0
+	return a.LoseHP(amount)
+}
+
+// LoseHP ignoring the block, returns the HP lost
+func (a *Actor) LoseHP(amount uint) uint {
+	if amount > a.HP {
+		amount = a.HP
+	}
+	a.HP -= amount
+	return amount
+}
+
+// Heal the actor, HP won't exceed MaxHP, returns the HP healed
+func (a *Actor) Heal(amount uint) uint {
+	if a.HP >= a.MaxHP {
+		return 0
+	}
+	if a.HP+amount > a.MaxHP {
+		amount = a.MaxHP - a.HP
+	}
+	a.HP += amount
+	return amount
+}
+
+// Dead if the actor has no HP left
+func (a *Actor) Dead() bool {
+	return a.HP == 0
+}
+
 // Player -
 type Player struct {
 	Actor
+	// Class is the id of the character class, empty for the classless player
+	Class string
+	// Energy at the start of each turn, 0 for the default
+	Energy int
+}
+
+// Marshal the player into data
+func (p *Player) Marshal() Data {
+	d := p.Actor.Marshal()
+	d.Class = p.Class
+	d.Energy = p.Energy
+	return d
+}
+
+// Unmarshal the player from data
+func (p *Player) Unmarshal(d Data) {
+	p.Actor.Unmarshal(d)
+	p.Class = d.Class
+	p.Energy = d.Energy
 }
 
 // Monster -
 type Monster struct {
 	Actor
+	// Kind is the id of the monster def
+	Kind string
+	// Intent is the index of the next move
+	Intent int
+}
+
+// Marshal the monster into data
+func (m *Monster) Marshal() Data {
+	d := m.Actor.Marshal()
+	d.Kind = m.Kind
+	d.Intent = m.Intent
+	return d
+}
+
+// Unmarshal the monster from data
+func (m *Monster) Unmarshal(d Data) {
+	m.Actor.Unmarshal(d)
+	m.Kind = d.Kind
+	m.Intent = d.Intent
 }
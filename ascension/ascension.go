@@ -0,0 +1,182 @@
+package ascension
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("ascension level is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("ascension level is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid ascension level def")
+)
+
+// Modifier of the level, it either changes the named value of the run by percent,
+// e.g. {"value": "elite_hp", "percent": 10}, or adds a card into the starter deck,
+// e.g. {"card": "ascenders_bane"}
+type Modifier struct {
+	Value   string `json:"value,omitempty"`
+	Percent int    `json:"percent,omitempty"`
+	Card    string `json:"card,omitempty"`
+}
+
+// Def is the data-defined ascension level,
+// the modifiers of all the lower levels are applied with its own
+type Def struct {
+	Level     int        `json:"level"`
+	Name      string     `json:"name"`
+	Modifiers []Modifier `json:"modifiers"`
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[int]*Def)
+)
+
+// Define the ascension level, the cards of the modifiers must be defined first
+func Define(def *Def) error {
+	if def.Level <= 0 {
+		return ErrInvalidDef
+	}
+
+	for _, m := range def.Modifiers {
+		if (m.Value == "") == (m.Card == "") || (m.Card != "" && m.Percent != 0) {
+			return ErrInvalidDef
+		}
+		if m.Card != "" {
+			if _, err := library.Get(m.Card); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.Level]; ok {
+		return ErrDefined
+	}
+	defs[def.Level] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def of the level
+func Get(level int) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[level]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by level
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Level < ds[j].Level })
+	return ds
+}
+
+// Modifiers of the level, including the ones of all the lower levels
+func Modifiers(level int) []Modifier {
+	var ms []Modifier
+	for _, def := range Defs() {
+		if def.Level > level {
+			break
+		}
+		ms = append(ms, def.Modifiers...)
+	}
+	return ms
+}
+
+// Percent of the named value changed by the level
+func Percent(level int, value string) int {
+	p := 0
+	for _, m := range Modifiers(level) {
+		if m.Value == value {
+			p += m.Percent
+		}
+	}
+	return p
+}
+
+func init() {
+	run.Modify(modify)
+}
+
+// modify the value of the run by its level
+func modify(r *run.Run, name string, value int) int {
+	if r.Ascension <= 0 {
+		return value
+	}
+	return value * (100 + Percent(r.Ascension, name)) / 100
+}
+
+// Start the run at the level, e.g. right after it's created by the class,
+// the cards of the modifiers are added into the starter deck
+func Start(r *run.Run, level int) error {
+	if level != 0 {
+		if _, err := Get(level); err != nil {
+			return err
+		}
+	}
+
+	var cs []*library.Card
+	for _, m := range Modifiers(level) {
+		if m.Card == "" {
+			continue
+		}
+		c, err := library.New(m.Card)
+		if err != nil {
+			return err
+		}
+		cs = append(cs, c)
+	}
+
+	err := r.State.Update(func(tx *store.Tx) error {
+		deck := tx.Pile(store.Deck)
+		for _, c := range cs {
+			*deck = append(*deck, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.Ascension = level
+	return nil
+}
@@ -0,0 +1,118 @@
+package ascension
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/potions"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/rest"
+	"github.com/sleep2death/hexcore/rewards"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"level": 1, "name": "Elites", "modifiers": [{"value": "elite_hp", "percent": 10}]},
+	{"level": 2, "name": "Tired", "modifiers": [{"value": "rest_heal", "percent": -50}]},
+	{"level": 3, "name": "Poverty", "modifiers": [
+		{"value": "reward_gold", "percent": -50}, {"value": "potion_chance", "percent": -100}
+	]},
+	{"level": 4, "name": "Cursed", "modifiers": [{"card": "asc_bane"}, {"value": "elite_hp", "percent": 10}]}
+]`
+
+func init() {
+	library.Define(&library.Def{ID: "asc_bane", Name: "Bane", Type: library.Curse, Rarity: library.Special})
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{Level: 1}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{Level: 0}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{Level: 5, Modifiers: []Modifier{{Percent: 10}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{Level: 5, Modifiers: []Modifier{{Value: "gold", Card: "asc_bane"}}}))
+	assert.Equal(t, library.ErrNotDefined, Define(&Def{Level: 5, Modifiers: []Modifier{{Card: "x"}}}))
+
+	_, err := Get(5)
+	assert.Equal(t, ErrNotDefined, err)
+	assert.Equal(t, 4, len(Defs()))
+
+	// the modifiers are cumulative
+	assert.Len(t, Modifiers(0), 0)
+	assert.Len(t, Modifiers(2), 2)
+	assert.Equal(t, 10, Percent(3, "elite_hp"))
+	assert.Equal(t, 20, Percent(4, "elite_hp"))
+	assert.Equal(t, 0, Percent(4, "monster_damage"))
+}
+
+func TestStart(t *testing.T) {
+	r := run.New("asc", 1)
+	assert.Nil(t, Start(r, 3))
+	assert.Equal(t, 3, r.Ascension)
+	assert.Len(t, r.State.GetPile(store.Deck), 0)
+	assert.Equal(t, 110, r.Value("elite_hp", 100))
+	assert.Equal(t, 50, r.Value("rest_heal", 100))
+
+	r = run.New("asc", 1)
+	assert.Nil(t, Start(r, 4))
+	deck := r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 1)
+	assert.Equal(t, "asc_bane", deck[0].Type())
+	assert.Equal(t, 120, r.Value("elite_hp", 100))
+
+	r = run.New("asc", 1)
+	assert.Equal(t, ErrNotDefined, Start(r, 5))
+	assert.Equal(t, ErrNotDefined, Start(r, -1))
+	assert.Nil(t, Start(r, 0))
+	assert.Equal(t, 100, r.Value("elite_hp", 100))
+}
+
+func TestRewards(t *testing.T) {
+	normal, hard := run.New("normal", 9), run.New("hard", 9)
+	assert.Nil(t, Start(hard, 3))
+
+	for i := 0; i < 5; i++ {
+		a, b := rewards.Generate(normal, monsters.Normal), rewards.Generate(hard, monsters.Normal)
+		assert.Equal(t, a.Gold/2, b.Gold)
+	}
+
+	// no potion is dropped, so the chance keeps raising
+	assert.Equal(t, 5*potions.DropStep, hard.Counters["potion_chance"])
+}
+
+func TestRest(t *testing.T) {
+	r := run.New("rest", 1)
+	assert.Nil(t, Start(r, 2))
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 10
+		tx.Player().MaxHP = 80
+		return nil
+	})
+
+	ctx := actions.NewContext(nil, make(chan []byte, 64), store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+	assert.Nil(t, execute(ctx, &rest.Enter{}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "rest"}))
+
+	// 30% of 80, halved
+	assert.Equal(t, uint(22), r.State.Player().HP)
+}
@@ -0,0 +1,149 @@
+package battle
+
+import (
+	"errors"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefeat -
+	ErrDefeat = errors.New("player is defeated")
+	// ErrUnplayable -
+	ErrUnplayable = errors.New("card can't be played")
+	// ErrNotEnoughEnergy -
+	ErrNotEnoughEnergy = errors.New("not enough energy")
+	// ErrNotInBattle -
+	ErrNotInBattle = errors.New("not in battle")
+	// ErrOutOfRange -
+	ErrOutOfRange = errors.New("hex is out of the card's range")
+)
+
+const (
+	// Energy of the player at the start of each turn, unless it's set by the class
+	Energy = 3
+	// HandSize is the number of cards drawn at the start of each turn
+	HandSize = 5
+)
+
+// Start action starts the battle with the monsters, the counters are reset,
+// the master deck is cloned into the draw pile,
+// so the changes in the battle won't be kept
+type Start struct {
+	Monsters []actors.Monster
+}
+
+// Exec -
+func (a *Start) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		draw := make(cards.Pile, 0, len(*tx.Pile(store.Deck)))
+		for _, c := range *tx.Pile(store.Deck) {
+			clone, err := cards.Clone(c)
+			if err != nil {
+				return err
+			}
+			draw = append(draw, clone)
+		}
+
+		*tx.Pile(store.Draw) = draw
+		*tx.Pile(store.Hand) = make(cards.Pile, 0)
+		*tx.Pile(store.Discard) = make(cards.Pile, 0)
+		*tx.Pile(store.Exhaust) = make(cards.Pile, 0)
+		tx.Shuffle(store.Draw)
+
+		tx.SetMonsters(a.Monsters)
+		tx.ResetCounters()
+		tx.Player().Block = 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []actions.Action{&StartTurn{First: true}}, nil
+}
+
+// Check action ends the battle when the player or all the monsters are dead,
+// otherwise the actions of Then are executed
+type Check struct {
+	Then []actions.Action
+}
+
+// Exec -
+func (a *Check) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	state := store.GetStore().State(ctx.ID())
+
+	if p := state.Player(); p.Dead() {
+		return []actions.Action{
+			&actions.Emit{Event: "defeat"},
+			actions.ActionFunc(func(ctx *actions.Context) ([]actions.Action, error) {
+				return nil, ErrDefeat
+			}),
+		}, nil
+	}
+
+	ms := state.Monsters()
+	if len(ms) == 0 {
+		return nil, nil
+	}
+
+	for _, m := range ms {
+		if !m.Dead() {
+			return a.Then, nil
+		}
+	}
+	return []actions.Action{&Victory{}}, nil
+}
+
+// Victory action ends the battle, and clears the battle piles,
+// the battle end hooks, e.g. the reward screen, follow the victory event
+type Victory struct {
+}
+
+// Exec -
+func (a *Victory) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	state := store.GetStore().State(ctx.ID())
+
+	err := state.Update(func(tx *store.Tx) error {
+		for _, name := range []store.PileName{store.Draw, store.Hand, store.Discard, store.Exhaust} {
+			*tx.Pile(name) = make(cards.Pile, 0)
+		}
+		tx.SetMonsters(nil)
+		tx.SetEnergy(0)
+		tx.ResetCounters()
+		tx.Player().Block = 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	state.EndTurn()
+
+	return []actions.Action{
+		&actions.Emit{Event: "victory"},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.BattleEnd}},
+	}, nil
+}
+
+// inBattle returns ErrNotInBattle if there is no living monster
+func inBattle(tx *store.Tx) error {
+	for _, m := range tx.Monsters() {
+		if !m.Dead() {
+			return nil
+		}
+	}
+	return ErrNotInBattle
+}
+
+// triggers of the events
+func triggers(events []*hooks.Event) []actions.Action {
+	next := make([]actions.Action, 0, len(events))
+	for _, ev := range events {
+		next = append(next, &hooks.Trigger{Event: *ev})
+	}
+	return next
+}
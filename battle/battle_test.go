@@ -0,0 +1,468 @@
+package battle
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hex"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/router"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	library.Define(&library.Def{ID: "battle_strike", Name: "Strike", Type: library.Attack, Cost: 1,
+		Effects: []effects.Effect{{Op: effects.Damage, Amount: 6}}})
+	library.Define(&library.Def{ID: "battle_defend", Name: "Defend", Type: library.Skill, Cost: 1,
+		Effects: []effects.Effect{{Op: effects.Block, Amount: 5}}})
+	library.Define(&library.Def{ID: "battle_offering", Name: "Offering", Type: library.Skill, Cost: 0, Exhaust: true,
+		Effects: []effects.Effect{{Op: effects.LoseHP, Amount: 6}, {Op: effects.Draw, Amount: 3}}})
+	library.Define(&library.Def{ID: "battle_fireball", Name: "Fireball", Type: library.Attack, Cost: 1, Range: 2,
+		Effects: []effects.Effect{{Op: effects.Damage, Amount: 6, Target: effects.Hex, Radius: 1}}})
+
+	library.Define(&library.Def{ID: "battle_wound", Name: "Wound", Type: library.Status, Unplayable: true})
+	library.Define(&library.Def{ID: "battle_dazed", Name: "Dazed", Type: library.Status, Unplayable: true, Ethereal: true})
+	library.Define(&library.Def{ID: "battle_burn", Name: "Burn", Type: library.Status, Unplayable: true,
+		EndTurn: []effects.Effect{{Op: effects.Damage, Amount: 2, Target: effects.Self}}})
+	library.Define(&library.Def{ID: "battle_slimed", Name: "Slimed", Type: library.Status, Cost: 1, Exhaust: true})
+	library.Define(&library.Def{ID: "battle_regret", Name: "Regret", Type: library.Curse, Unplayable: true})
+
+	monsters.Define(&monsters.Def{ID: "battle_hexer", MinHP: 10, MaxHP: 10, Moves: []monsters.Move{
+		{ID: "hex", Effects: []effects.Effect{
+			{Op: effects.AddCard, Card: "battle_regret", Pile: "discard"},
+			{Op: effects.AddCard, Card: "battle_wound", Pile: "draw", Position: effects.Random},
+		}},
+	}})
+	monsters.Define(&monsters.Def{ID: "battle_cultist", MinHP: 40, MaxHP: 40, Moves: []monsters.Move{
+		{ID: "incantation", Effects: []effects.Effect{{Op: effects.Block, Amount: 3}}},
+		{ID: "dark_strike", Effects: []effects.Effect{{Op: effects.Damage, Amount: 8}}},
+	}})
+}
+
+// execute the action and all its next actions, without waiting for the input
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// events sent to the output
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func newBattle(deck ...string) (*actions.Context, *store.State, chan []byte) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().SetID("player")
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 50
+		for _, id := range deck {
+			c, _ := library.New(id)
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+		return nil
+	})
+
+	outc := make(chan []byte, 256)
+	return actions.NewContext(nil, outc, store.GetStore().AddState(state)), state, outc
+}
+
+func monster(id string, hp uint) actors.Monster {
+	m := actors.Monster{}
+	m.SetID(id)
+	m.HP = hp
+	m.MaxHP = hp
+	return m
+}
+
+// find the card of the type in the pile
+func find(p cards.Pile, typ string) string {
+	for _, c := range p {
+		if c.Type() == typ {
+			return c.ID()
+		}
+	}
+	return ""
+}
+
+func TestBattle(t *testing.T) {
+	ctx, state, outc := newBattle(
+		"battle_strike", "battle_strike", "battle_strike", "battle_strike", "battle_strike",
+		"battle_defend", "battle_defend", "battle_defend", "battle_defend", "battle_defend")
+
+	shuffles := 0
+	defer hooks.Listen(func(c *actions.Context, ev *hooks.Event) []actions.Action {
+		if c == ctx && ev.Hook == hooks.Shuffle {
+			shuffles++
+		}
+		return nil
+	})()
+
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 12)}}))
+	assert.Equal(t, []string{"turn_start"}, events(outc))
+	assert.Equal(t, 5, len(state.GetPile(store.Hand)))
+	assert.Equal(t, 5, len(state.GetPile(store.Draw)))
+	assert.Equal(t, Energy, state.Energy())
+
+	// the deck is cloned, not moved
+	assert.Equal(t, 10, len(state.GetPile(store.Deck)))
+
+	hand := state.GetPile(store.Hand)
+	strike := find(hand, "battle_strike")
+	defend := find(hand, "battle_defend")
+	if strike == "" || defend == "" {
+		t.Fatal("unexpected hand:", hand)
+	}
+
+	// target is required
+	assert.Nil(t, execute(ctx, &PlayCard{ID: strike}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+	assert.Nil(t, execute(ctx, &PlayCard{ID: strike, Target: "goblin"}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	assert.Nil(t, execute(ctx, &PlayCard{ID: strike, Target: "slime"}))
+	assert.Equal(t, uint(6), state.Monsters()[0].HP)
+	assert.Equal(t, Energy-1, state.Energy())
+	assert.Equal(t, 1, len(state.GetPile(store.Discard)))
+
+	assert.Nil(t, execute(ctx, &PlayCard{ID: defend}))
+	assert.Equal(t, uint(5), state.Player().Block)
+
+	// the played card is not in hand anymore
+	assert.Nil(t, execute(ctx, &PlayCard{ID: strike, Target: "slime"}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	// two turns to empty the draw pile, the third one shuffles the discard pile
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, 5, len(state.GetPile(store.Hand)))
+	assert.Equal(t, 0, len(state.GetPile(store.Draw)))
+	assert.Equal(t, 0, shuffles)
+	assert.Equal(t, uint(0), state.Player().Block)
+
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, 5, len(state.GetPile(store.Hand)))
+	assert.Equal(t, 5, len(state.GetPile(store.Draw)))
+	assert.Equal(t, 1, shuffles)
+	assert.Equal(t, []string{"turn_start", "turn_start"}, events(outc))
+
+	// drain the energy
+	for state.Energy() > 0 {
+		hand := state.GetPile(store.Hand)
+		assert.Nil(t, execute(ctx, &PlayCard{ID: find(hand, "battle_defend") + find(hand, "battle_strike")[:0], Target: "slime"}))
+		if find(state.GetPile(store.Hand), "battle_defend") == "" {
+			break
+		}
+	}
+	events(outc)
+
+	if state.Energy() == 0 {
+		if id := find(state.GetPile(store.Hand), "battle_strike"); id != "" {
+			assert.Nil(t, execute(ctx, &PlayCard{ID: id, Target: "slime"}))
+			assert.Equal(t, []string{"rejected"}, events(outc))
+		}
+	}
+
+	// kill the slime with strikes
+	for len(state.Monsters()) > 0 {
+		id := find(state.GetPile(store.Hand), "battle_strike")
+		if id == "" || state.Energy() == 0 {
+			assert.Nil(t, execute(ctx, &EndTurn{}))
+			continue
+		}
+		assert.Nil(t, execute(ctx, &PlayCard{ID: id, Target: "slime"}))
+	}
+
+	evs := events(outc)
+	assert.Equal(t, "victory", evs[len(evs)-1])
+	assert.Equal(t, 0, len(state.GetPile(store.Hand)))
+	assert.Equal(t, 0, len(state.GetPile(store.Draw)))
+	assert.Equal(t, 0, len(state.GetPile(store.Discard)))
+	assert.Equal(t, 10, len(state.GetPile(store.Deck)))
+
+	// no battle, no input
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+}
+
+func TestExhaustAndDefeat(t *testing.T) {
+	ctx, state, outc := newBattle("battle_offering", "battle_strike", "battle_strike",
+		"battle_strike", "battle_strike", "battle_strike", "battle_strike", "battle_strike")
+
+	exhausted := 0
+	defer hooks.Listen(func(c *actions.Context, ev *hooks.Event) []actions.Action {
+		if c == ctx && ev.Hook == hooks.CardExhausted {
+			exhausted++
+		}
+		return nil
+	})()
+
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 12)}}))
+	for find(state.GetPile(store.Hand), "battle_offering") == "" {
+		assert.Nil(t, execute(ctx, &EndTurn{}))
+	}
+
+	assert.Nil(t, execute(ctx, &PlayCard{ID: find(state.GetPile(store.Hand), "battle_offering")}))
+	assert.Equal(t, 1, exhausted)
+	assert.Equal(t, 1, len(state.GetPile(store.Exhaust)))
+	assert.Equal(t, 7, len(state.GetPile(store.Hand)))
+	assert.Equal(t, uint(44), state.Player().HP)
+	events(outc)
+
+	err := execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 100, Target: effects.Player}}, Source: "slime"})
+	assert.Nil(t, err)
+	assert.Equal(t, ErrDefeat, execute(ctx, &Check{}))
+	assert.Equal(t, []string{"defeat"}, events(outc))
+}
+
+func TestMonsterTurn(t *testing.T) {
+	ctx, state, outc := newBattle("battle_defend", "battle_defend", "battle_defend", "battle_defend", "battle_defend")
+
+	m, err := monsters.New(rand.New(rand.NewSource(1)), "battle_cultist", "cultist")
+	assert.Nil(t, err)
+	m.Intent = 1
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{m, monster("dummy", 10)}}))
+
+	// the intents are sent at the start of the turn, the monster without a def has no intent
+	data := <-outc
+	assert.Equal(t, `{"event":"turn_start","data":[{"monster":"cultist","move":"dark_strike"}]}`, string(data))
+
+	assert.Nil(t, execute(ctx, &PlayCard{ID: state.GetPile(store.Hand)[0].ID()}))
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, []string{"monster_move", "turn_start"}, events(outc))
+
+	// 3 damage is taken after the block
+	assert.Equal(t, uint(47), state.Player().HP)
+
+	// the monster moves every turn
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, execute(ctx, &EndTurn{}))
+		assert.Equal(t, []string{"monster_move", "turn_start"}, events(outc))
+	}
+	assert.True(t, state.Player().HP < 47 || state.Monsters()[0].Block > 0)
+}
+
+func TestMonsterDamage(t *testing.T) {
+	ctx, state, outc := newBattle("battle_strike")
+
+	// the damage is modified only if a run is attached
+	defer run.Modify(func(r *run.Run, name string, value int) int {
+		if name == MonsterDamage {
+			return value + 2
+		}
+		return value
+	})()
+	r := run.New("battle", 1)
+	r.State = state
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+
+	m, _ := monsters.New(rand.New(rand.NewSource(1)), "battle_cultist", "cultist")
+	m.Intent = 1
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{m}}))
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	events(outc)
+	assert.Equal(t, uint(40), state.Player().HP)
+
+	// the def is untouched
+	def, _ := monsters.Get("battle_cultist")
+	assert.Equal(t, 8, def.Moves[1].Effects[0].Amount)
+}
+
+func TestStatusCards(t *testing.T) {
+	ctx, state, outc := newBattle("battle_wound", "battle_dazed", "battle_burn", "battle_slimed", "battle_strike")
+
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 40)}}))
+	events(outc)
+	hand := state.GetPile(store.Hand)
+	assert.Len(t, hand, 5)
+
+	// the unplayable cards are rejected
+	for _, id := range []string{"battle_wound", "battle_dazed", "battle_burn"} {
+		assert.Nil(t, execute(ctx, &PlayCard{ID: find(hand, id)}))
+		assert.Equal(t, []string{"rejected"}, events(outc))
+	}
+
+	// slimed is paid and exhausted
+	energy := state.Energy()
+	assert.Nil(t, execute(ctx, &PlayCard{ID: find(hand, "battle_slimed")}))
+	assert.Equal(t, energy-1, state.Energy())
+
+	// burn deals damage at the end of the turn, and the dazed is exhausted
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, uint(48), state.Player().HP)
+	var exhausted []string
+	for _, c := range state.GetPile(store.Exhaust) {
+		exhausted = append(exhausted, c.Type())
+	}
+	assert.Equal(t, []string{"battle_slimed", "battle_dazed"}, exhausted)
+}
+
+func TestCurses(t *testing.T) {
+	ctx, state, outc := newBattle("battle_strike")
+
+	m, _ := monsters.New(rand.New(rand.NewSource(1)), "battle_hexer", "hexer")
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{m}}))
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	assert.Equal(t, []string{"turn_start", "monster_move", "turn_start"}, events(outc))
+
+	// the curse is added into the deck too, the wound is not
+	all := append(append(state.GetPile(store.Hand), state.GetPile(store.Draw)...), state.GetPile(store.Discard)...)
+	assert.NotEqual(t, "", find(all, "battle_regret"))
+	assert.NotEqual(t, "", find(all, "battle_wound"))
+	deck := state.GetPile(store.Deck)
+	assert.Len(t, deck, 2)
+	assert.Equal(t, "battle_regret", deck[1].Type())
+
+	// the curse stays in the deck after the battle
+	assert.Nil(t, execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 10}}, Target: "hexer"}))
+	assert.Nil(t, execute(ctx, &Check{}))
+	assert.Equal(t, []string{"victory"}, events(outc))
+	assert.Len(t, state.GetPile(store.Deck), 2)
+	assert.Len(t, state.GetPile(store.Draw), 0)
+}
+
+func TestHexCard(t *testing.T) {
+	ctx, state, outc := newBattle("battle_fireball", "battle_fireball", "battle_fireball", "battle_fireball", "battle_fireball")
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().Place(hex.Coord{Q: 0, R: 0})
+		return nil
+	})
+
+	slime := monster("slime", 20)
+	slime.Place(hex.Coord{Q: 2, R: 0})
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{slime, monster("louse", 10)}}))
+	events(outc)
+
+	// the hex is required, and must be in range
+	id := state.GetPile(store.Hand)[0].ID()
+	assert.Nil(t, execute(ctx, &PlayCard{ID: id}))
+	assert.Nil(t, execute(ctx, &PlayCard{ID: id, Hex: &hex.Coord{Q: 3, R: 0}}))
+	assert.Equal(t, []string{"rejected", "rejected"}, events(outc))
+	assert.Equal(t, Energy, state.Energy())
+
+	assert.Nil(t, execute(ctx, &PlayCard{ID: id, Hex: &hex.Coord{Q: 2, R: -1}}))
+	ms := state.Monsters()
+	assert.Equal(t, uint(14), ms[0].HP)
+	assert.Equal(t, uint(10), ms[1].HP)
+	assert.Equal(t, uint(50), state.Player().HP)
+}
+
+func TestClassEnergy(t *testing.T) {
+	ctx, state, outc := newBattle("battle_strike")
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().Energy = 4
+		return nil
+	})
+
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 12)}}))
+	events(outc)
+	assert.Equal(t, 4, state.Energy())
+}
+
+func TestCheckpoint(t *testing.T) {
+	b := store.NewMemoryBackend()
+	store.GetStore().SetBackend(b)
+	defer store.GetStore().SetBackend(nil)
+
+	ctx, state, outc := newBattle("battle_strike", "battle_strike", "battle_strike", "battle_strike", "battle_strike",
+		"battle_strike", "battle_strike")
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 50)}}))
+	events(outc)
+
+	// the first turn is saved after the cards are drawn
+	first, err := store.GetStore().Load(state.Session())
+	assert.Nil(t, err)
+	assert.Equal(t, state.Snapshot(), first.Snapshot())
+
+	// and every turn after it
+	assert.Nil(t, execute(ctx, &EndTurn{}))
+	events(outc)
+	saved, err := store.GetStore().Load(state.Session())
+	assert.Nil(t, err)
+	assert.Equal(t, state.Snapshot(), saved.Snapshot())
+	assert.NotEqual(t, first.Snapshot(), saved.Snapshot())
+}
+
+func TestRoute(t *testing.T) {
+	r := router.New()
+	Route(r)
+
+	action, err := r.Serve(&router.Request{Type: router.Card, Path: "/card/play/c1", Payload: []byte(`{"target":"slime"}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, &PlayCard{ID: "c1", Target: "slime"}, action)
+
+	action, err = r.Serve(&router.Request{Type: router.Card, Path: "/card/play/c1"})
+	assert.Nil(t, err)
+	assert.Equal(t, &PlayCard{ID: "c1"}, action)
+
+	action, err = r.Serve(&router.Request{Type: router.Card, Path: "/card/play/c1/hex/2/-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, &PlayCard{ID: "c1", Hex: &hex.Coord{Q: 2, R: -1}}, action)
+
+	_, err = r.Serve(&router.Request{Type: router.Card, Path: "/card/play/c1/hex/x/1"})
+	assert.NotNil(t, err)
+
+	action, err = r.Serve(&router.Request{Type: router.Battle, Path: "/battle/end_turn"})
+	assert.Nil(t, err)
+	assert.Equal(t, &EndTurn{}, action)
+}
+
+func TestUndoRoute(t *testing.T) {
+	r := router.New()
+	r.Use(router.Recovery(func(v interface{}) actions.Action { return nil }))
+	r.Use(router.Logger(func(format string, v ...interface{}) {}))
+	Route(r)
+
+	_, state, outc := newBattle("battle_strike", "battle_strike", "battle_strike", "battle_strike", "battle_strike")
+	in := make(chan actions.Action, 1)
+	ctx := actions.NewContext(in, outc, store.GetStore().AddState(state))
+	assert.Nil(t, execute(ctx, &Start{Monsters: []actors.Monster{monster("slime", 50)}}))
+	events(outc)
+
+	// the routed input is read by the chain
+	input := func(path string) {
+		action, err := r.Serve(&router.Request{Type: router.Card, Path: path, Payload: []byte(`{"target":"slime"}`)})
+		assert.Nil(t, err)
+		in <- action
+		assert.Nil(t, execute(ctx, &actions.WaitForInput{}))
+	}
+
+	input("/card/play/" + state.GetPile(store.Hand)[0].ID())
+	assert.Equal(t, 4, len(state.GetPile(store.Hand)))
+	assert.Nil(t, state.CanUndo())
+
+	// the rejected input leaves no checkpoint
+	input("/card/play/unknown")
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	assert.Nil(t, state.Undo())
+	assert.Equal(t, 5, len(state.GetPile(store.Hand)))
+	assert.Equal(t, uint(50), state.Monsters()[0].HP)
+	assert.Equal(t, store.ErrNothingToUndo, state.Undo())
+}
@@ -0,0 +1,126 @@
+package battle
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// MonsterDamage is the damage of the monster moves, modified by the run modifiers if a run is attached
+const MonsterDamage = "monster_damage"
+
+// Intent of the monster, which is sent to the output at the start of the player's turn
+type Intent struct {
+	Monster string `json:"monster"`
+	Move    string `json:"move"`
+}
+
+// intents of the living monsters
+func intents(state *store.State) []Intent {
+	var is []Intent
+	for _, m := range state.Monsters() {
+		if m.Dead() {
+			continue
+		}
+
+		def, err := monsters.Get(m.Kind)
+		if err != nil {
+			continue
+		}
+		is = append(is, Intent{Monster: m.ID(), Move: def.Moves[m.Intent%len(def.Moves)].ID})
+	}
+	return is
+}
+
+// MonsterTurn action, every living monster makes its move one by one,
+// then chooses the next move.
+// The monsters without a def do nothing.
+type MonsterTurn struct {
+}
+
+// Exec -
+func (a *MonsterTurn) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var next []actions.Action
+	for _, m := range store.GetStore().State(ctx.ID()).Monsters() {
+		next = append(next, &monsterMove{monster: m.ID()})
+	}
+	return append(next, &chooseMoves{}), nil
+}
+
+// monsterMove action makes the move of the monster,
+// if it's still alive, and the player is not dead
+type monsterMove struct {
+	monster string
+}
+
+// Exec -
+func (a *monsterMove) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var (
+		move   *monsters.Move
+		player string
+	)
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		m, err := tx.Monster(a.monster)
+		if err != nil {
+			return err
+		}
+
+		if m.Dead() || tx.Player().Dead() {
+			return nil
+		}
+
+		def, err := monsters.Get(m.Kind)
+		if err != nil {
+			return nil
+		}
+
+		m.Block = 0
+		move = &def.Moves[m.Intent%len(def.Moves)]
+		player = tx.Player().ID()
+		return nil
+	})
+	if err != nil || move == nil {
+		return nil, err
+	}
+
+	es := move.Effects
+	if r := run.Of(ctx); r != nil {
+		es = make([]effects.Effect, len(move.Effects))
+		for i, e := range move.Effects {
+			if e.Op == effects.Damage {
+				if e.Amount = r.Value(MonsterDamage, e.Amount); e.Amount < 0 {
+					e.Amount = 0
+				}
+			}
+			es[i] = e
+		}
+	}
+
+	return []actions.Action{
+		&actions.Emit{Event: "monster_move", Data: &Intent{Monster: a.monster, Move: move.ID}},
+		&effects.Apply{Effects: es, Source: a.monster, Target: player},
+	}, nil
+}
+
+// chooseMoves action chooses the next moves of the living monsters
+type chooseMoves struct {
+}
+
+// Exec -
+func (a *chooseMoves) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	return nil, store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		ms := tx.Monsters()
+		for i := range ms {
+			if ms[i].Dead() {
+				continue
+			}
+			if def, err := monsters.Get(ms[i].Kind); err == nil {
+				ms[i].Intent = def.NextMove(tx.Rand())
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package battle
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hex"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// Strength is the value counter added to the damage of the cards played by the player
+const Strength = "strength"
+
+// PlayCard input action plays the card in hand,
+// the card is paid, moved into the discard (or exhaust) pile, then its effects are applied
+type PlayCard struct {
+	ID string
+	// Target actor id, required if the card has a chosen target
+	Target string
+	// Hex chosen by the player, required if the card targets a hex
+	Hex *hex.Coord
+}
+
+// Undoable -
+func (a *PlayCard) Undoable() bool {
+	return true
+}
+
+// Exec -
+func (a *PlayCard) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var card *library.Card
+	var strength int
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		if err := inBattle(tx); err != nil {
+			return err
+		}
+
+		c, _, err := tx.Pile(store.Hand).FindCard(a.ID)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if card, ok = library.Of(c); !ok || card.Def().Unplayable {
+			return ErrUnplayable
+		}
+
+		if card.Cost() > tx.Energy() {
+			return ErrNotEnoughEnergy
+		}
+
+		if effects.NeedsTarget(card.Effects()) {
+			if a.Target == "" {
+				return effects.ErrNoTarget
+			}
+			m, err := tx.Monster(a.Target)
+			if err != nil {
+				return err
+			}
+			if m.Dead() {
+				return store.ErrActorNotExist
+			}
+		}
+
+		if effects.NeedsHex(card.Effects()) {
+			if a.Hex == nil {
+				return effects.ErrNoHex
+			}
+			pos, ok := tx.Player().Position()
+			if r := card.Def().Range; r > 0 && ok && pos.Distance(*a.Hex) > r {
+				return ErrOutOfRange
+			}
+		}
+
+		tx.SetEnergy(tx.Energy() - card.Cost())
+
+		to := store.Discard
+		if card.Def().Exhaust {
+			to = store.Exhaust
+		}
+		strength = tx.Count(Strength)
+		_, err = tx.Pick(a.ID, store.Hand, to)
+		return err
+	})
+	if err != nil {
+		actions.Unmark(ctx)
+		return actions.Reject("play_card", err), nil
+	}
+
+	next := []actions.Action{
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.CardPlayed, Card: card}},
+		&effects.Apply{Effects: strengthened(card.Effects(), strength), Target: a.Target, Hex: a.Hex},
+	}
+	if card.Def().Exhaust {
+		next = append(next, &hooks.Trigger{Event: hooks.Event{Hook: hooks.CardExhausted, Card: card}})
+	}
+	return append(next, &Check{}), nil
+}
+
+// strengthened copy of the effects, the strength is added to the damage, down to 0
+func strengthened(es []effects.Effect, strength int) []effects.Effect {
+	if strength == 0 {
+		return es
+	}
+
+	ss := make([]effects.Effect, len(es))
+	for i, e := range es {
+		if e.Op == effects.Damage {
+			if e.Amount += strength; e.Amount < 0 {
+				e.Amount = 0
+			}
+		}
+		ss[i] = e
+	}
+	return ss
+}
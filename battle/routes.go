@@ -0,0 +1,65 @@
+package battle
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/hex"
+	"github.com/sleep2death/hexcore/router"
+)
+
+// TargetPayload of the input actions with a chosen target
+type TargetPayload struct {
+	Target string `json:"target,omitempty"`
+}
+
+// Route the battle input actions
+func Route(r *router.Router) {
+	r.Handle(router.Card, "/card/play/:id<id>", playCard,
+		router.WithDoc("play the card in hand"), router.WithPayload(TargetPayload{}))
+	r.Handle(router.Card, "/card/play/:id<id>/hex/:q<int>/:r<int>", playCardOnHex,
+		router.WithDoc("play the card in hand on the hex"))
+	r.Handle(router.Battle, "/battle/end_turn", endTurn,
+		router.WithDoc("end the player's turn"))
+}
+
+// DecodeTarget from the payload of the request, the payload is optional
+func DecodeTarget(req *router.Request) (string, error) {
+	p := &TargetPayload{}
+	if err := req.Decode(p); err != nil && err != router.ErrNoPayload {
+		return "", err
+	}
+	return p.Target, nil
+}
+
+func playCard(req *router.Request, ps router.Params) (actions.Action, error) {
+	id, err := ps.ID("id")
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := DecodeTarget(req)
+	if err != nil {
+		return nil, err
+	}
+	return &PlayCard{ID: id, Target: t}, nil
+}
+
+func playCardOnHex(req *router.Request, ps router.Params) (actions.Action, error) {
+	id, err := ps.ID("id")
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := ps.Int("q")
+	if err != nil {
+		return nil, err
+	}
+	r, err := ps.Int("r")
+	if err != nil {
+		return nil, err
+	}
+	return &PlayCard{ID: id, Hex: &hex.Coord{Q: q, R: r}}, nil
+}
+
+func endTurn(req *router.Request, ps router.Params) (actions.Action, error) {
+	return &EndTurn{}, nil
+}
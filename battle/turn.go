@@ -0,0 +1,116 @@
+package battle
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// StartTurn action resets the block and energy of the player,
+// and draws the cards of the turn, the state is checkpointed
+// before waiting for the input of the player
+type StartTurn struct {
+	// First turn of the battle, the battle start hook is fired before the turn start
+	First bool
+}
+
+// Exec -
+func (a *StartTurn) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var events []*hooks.Event
+
+	state := store.GetStore().State(ctx.ID())
+	err := state.Update(func(tx *store.Tx) (err error) {
+		tx.Player().Block = 0
+		if e := tx.Player().Energy; e > 0 {
+			tx.SetEnergy(e)
+		} else {
+			tx.SetEnergy(Energy)
+		}
+		events, err = effects.DrawCards(tx, HandSize)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	next := triggers(events)
+	if a.First {
+		next = append(next, &hooks.Trigger{Event: hooks.Event{Hook: hooks.BattleStart}})
+	}
+	next = append(next,
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.TurnStart}},
+		&actions.Emit{Event: "turn_start", Data: intents(state)},
+	)
+	return append(next, &Check{}, &actions.Checkpoint{}), nil
+}
+
+// EndTurn input action ends the player's turn, the end of turn effects of the cards in hand are applied,
+// the ethereal ones are exhausted and the others are discarded,
+// then the monsters make their moves, and a new turn is started if the battle is not over
+type EndTurn struct {
+}
+
+// Exec -
+func (a *EndTurn) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	state := store.GetStore().State(ctx.ID())
+	if err := state.Update(inBattle); err != nil {
+		return actions.Reject("end_turn", err), nil
+	}
+	state.EndTurn()
+
+	discard := actions.ActionFunc(func(ctx *actions.Context) ([]actions.Action, error) {
+		return nil, state.Update(func(tx *store.Tx) error {
+			hand := tx.Pile(store.Hand)
+			*tx.Pile(store.Discard) = append(*tx.Pile(store.Discard), *hand...)
+			*hand = make(cards.Pile, 0)
+			return nil
+		})
+	})
+
+	return []actions.Action{
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.TurnEnd}},
+		&endOfTurn{},
+		discard,
+		&MonsterTurn{},
+		&Check{Then: []actions.Action{&StartTurn{}}},
+	}, nil
+}
+
+// endOfTurn action applies the end of turn effects of the cards in hand,
+// and exhausts the ethereal ones
+type endOfTurn struct {
+}
+
+// Exec -
+func (a *endOfTurn) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var next []actions.Action
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		// the ethereal cards are picked from the hand while iterating it
+		hand := append(cards.Pile{}, *tx.Pile(store.Hand)...)
+		for _, c := range hand {
+			card, ok := library.Of(c)
+			if !ok {
+				continue
+			}
+
+			if len(card.Def().EndTurn) > 0 {
+				next = append(next, &effects.Apply{Effects: card.Def().EndTurn})
+			}
+
+			if card.Def().Ethereal {
+				if _, err := tx.Pick(card.ID(), store.Hand, store.Exhaust); err != nil {
+					return err
+				}
+				next = append(next, &hooks.Trigger{Event: hooks.Event{Hook: hooks.CardExhausted, Card: card}})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
@@ -0,0 +1,100 @@
+package cards
+
+// Base implements the common parts of a card instance: id, name, upgrades and modifiers.
+// Embed it into the concrete card type, which only needs to implement
+// Type, String and Copy, and override Upgrade if the card has a limit.
+type Base struct {
+	id        string
+	name      string
+	upgrades  int
+	modifiers map[string]int
+}
+
+// SetName -
+func (b *Base) SetName(name string) {
+	b.name = name
+}
+
+// Name -
+func (b *Base) Name() string {
+	return b.name
+}
+
+// SetID -
+func (b *Base) SetID(id string) {
+	b.id = id
+}
+
+// ID -
+func (b *Base) ID() string {
+	return b.id
+}
+
+// Upgrade the card once
+func (b *Base) Upgrade() error {
+	b.upgrades++
+	return nil
+}
+
+// Upgrades - how many times the card is upgraded
+func (b *Base) Upgrades() int {
+	return b.upgrades
+}
+
+// Modifier of the card by key, 0 if not exist
+func (b *Base) Modifier(key string) int {
+	return b.modifiers[key]
+}
+
+// SetModifier of the card, 0 value removes the modifier
+func (b *Base) SetModifier(key string, value int) {
+	if value == 0 {
+		delete(b.modifiers, key)
+		return
+	}
+
+	if b.modifiers == nil {
+		b.modifiers = make(map[string]int)
+	}
+	b.modifiers[key] = value
+}
+
+// CopyBase returns a copy of the base, with the given new id
+func (b *Base) CopyBase(id string) Base {
+	c := *b
+	c.id = id
+	c.modifiers = nil
+	for k, v := range b.modifiers {
+		c.SetModifier(k, v)
+	}
+	return c
+}
+
+// Marshal -
+func (b *Base) Marshal() Data {
+	d := Data{
+		ID:       b.id,
+		Name:     b.name,
+		Upgrades: b.upgrades,
+	}
+
+	if len(b.modifiers) > 0 {
+		d.Modifiers = make(map[string]int, len(b.modifiers))
+		for k, v := range b.modifiers {
+			d.Modifiers[k] = v
+		}
+	}
+	return d
+}
+
+// Unmarshal -
+func (b *Base) Unmarshal(d Data) error {
+	b.id = d.ID
+	b.name = d.Name
+	b.upgrades = d.Upgrades
+	b.modifiers = nil
+	for k, v := range d.Modifiers {
+		b.SetModifier(k, v)
+	}
+	return nil
+}
@@ -35,14 +35,21 @@ type Card interface {
 	ID() string
 
 	Copy() Card
+
+	// Type name of the card, which is registered by RegisterType
+	Type() string
+	// Marshal the card instance into data, Type field can be left empty
+	Marshal() Data
+	// Unmarshal the card instance from data
+	Unmarshal(d Data) error
 }
 
 // Pile of the cards
 type Pile []Card
 
-// Shuffle the cards
-func (p *Pile) Shuffle() {
-	rand.Shuffle(len(*p), func(i, j int) { (*p)[i], (*p)[j] = (*p)[j], (*p)[i] })
+// Shuffle the cards with the given random generator
+func (p *Pile) Shuffle(r *rand.Rand) {
+	r.Shuffle(len(*p), func(i, j int) { (*p)[i], (*p)[j] = (*p)[j], (*p)[i] })
 }
 
 // Draw one card from the source pile
@@ -136,6 +143,26 @@ func (c *TestCard) Upgrade() error {
 	return errors.New("can't upgrade")
 }
 
+// Type -
+func (c *TestCard) Type() string {
+	return "TestCard"
+}
+
+// Marshal -
+func (c *TestCard) Marshal() Data {
+	return Data{
+		ID:   c.id,
+		Name: c.name,
+	}
+}
+
+// Unmarshal -
+func (c *TestCard) Unmarshal(d Data) error {
+	c.id = d.ID
+	c.name = d.Name
+	return nil
+}
+
 // Copy -
 func (c *TestCard) Copy() Card {
 	c.copied++
@@ -2,6 +2,7 @@ package cards
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +19,7 @@ func TestShuffle(t *testing.T) {
 	})
 
 	cards[3].SetID("3")
-	cards.Shuffle()
+	cards.Shuffle(rand.New(rand.NewSource(1)))
 	assert.Equal(t, "[<card f> <card a> <card b> <card c> <card e> <card 3>]", fmt.Sprint(cards))
 }
 
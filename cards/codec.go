@@ -0,0 +1,102 @@
+package cards
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrUnknownType -
+	ErrUnknownType = errors.New("card type is not registered")
+)
+
+// Data is the serializable form of a card instance
+type Data struct {
+	Type      string         `json:"type"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name,omitempty"`
+	Upgrades  int            `json:"upgrades,omitempty"`
+	Modifiers map[string]int `json:"modifiers,omitempty"`
+}
+
+var (
+	typesMu sync.RWMutex
+	types   = make(map[string]func() Card)
+)
+
+func init() {
+	RegisterType("TestCard", func() Card { return &TestCard{} })
+}
+
+// RegisterType registers the factory of the card type,
+// so the card instances of the type can be decoded from data.
+// It panics if the type is already registered.
+func RegisterType(name string, factory func() Card) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	if _, ok := types[name]; ok {
+		panic("card type '" + name + "' is already registered")
+	}
+	types[name] = factory
+}
+
+// New card of the registered type
+func New(name string) (Card, error) {
+	typesMu.RLock()
+	factory, ok := types[name]
+	typesMu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	return factory(), nil
+}
+
+// Encode the card into data with its type name
+func Encode(card Card) Data {
+	d := card.Marshal()
+	d.Type = card.Type()
+	return d
+}
+
+// Decode the card from data by its type name
+func Decode(d Data) (Card, error) {
+	card, err := New(d.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := card.Unmarshal(d); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// Clone the card with the same id through its data,
+// so modifying the clone won't change the original
+func Clone(card Card) (Card, error) {
+	return Decode(Encode(card))
+}
+
+// Encode every card of the pile
+func (p *Pile) Encode() []Data {
+	ds := make([]Data, 0, len(*p))
+	for _, card := range *p {
+		ds = append(ds, Encode(card))
+	}
+	return ds
+}
+
+// DecodePile decodes every card of the pile
+func DecodePile(ds []Data) (Pile, error) {
+	p := make(Pile, 0, len(ds))
+	for _, d := range ds {
+		card, err := Decode(d)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, card)
+	}
+	return p, nil
+}
@@ -0,0 +1,57 @@
+package cards
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type baseCard struct {
+	Base
+}
+
+func (c *baseCard) Type() string   { return "baseCard" }
+func (c *baseCard) String() string { return "<card " + c.ID() + ">" }
+func (c *baseCard) Copy() Card     { return &baseCard{Base: c.CopyBase("copy of " + c.ID())} }
+
+// unregisterType of the test, so the test can be run again
+func unregisterType(name string) {
+	typesMu.Lock()
+	delete(types, name)
+	typesMu.Unlock()
+}
+
+func TestCodec(t *testing.T) {
+	RegisterType("baseCard", func() Card { return &baseCard{} })
+	defer unregisterType("baseCard")
+	assert.Panics(t, func() {
+		RegisterType("baseCard", func() Card { return &baseCard{} })
+	})
+
+	b := &baseCard{}
+	b.SetID("b")
+	b.SetName("Bash")
+	b.Upgrade()
+	b.SetModifier("cost", -1)
+
+	p := Pile([]Card{&TestCard{id: "a", name: "Strike"}, b})
+	ds := p.Encode()
+	assert.Equal(t, "TestCard", ds[0].Type)
+	assert.Equal(t, Data{Type: "baseCard", ID: "b", Name: "Bash", Upgrades: 1, Modifiers: map[string]int{"cost": -1}}, ds[1])
+
+	decoded, err := DecodePile(ds)
+	assert.Nil(t, err)
+	assert.Equal(t, "[<card a> <card b>]", fmt.Sprint(decoded))
+	assert.Equal(t, "Strike", decoded[0].Name())
+	assert.Equal(t, -1, decoded[1].(*baseCard).Modifier("cost"))
+
+	// modifiers of the copy are not shared
+	c := b.Copy().(*baseCard)
+	c.SetModifier("cost", 0)
+	assert.Equal(t, 0, c.Modifier("cost"))
+	assert.Equal(t, -1, b.Modifier("cost"))
+
+	_, err = Decode(Data{Type: "unknown"})
+	assert.Equal(t, ErrUnknownType, err)
+}
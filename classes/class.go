@@ -0,0 +1,194 @@
+package classes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("class is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("class is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid class def")
+)
+
+// Entry of the starter deck, e.g. {"card": "strike", "count": 5}
+type Entry struct {
+	Card  string `json:"card"`
+	Count int    `json:"count"`
+}
+
+// Def is the data-defined character class
+type Def struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	HP   uint   `json:"hp"`
+	Gold int    `json:"gold,omitempty"`
+	// Energy at the start of each turn, 0 for the default of the battle
+	Energy int     `json:"energy,omitempty"`
+	Deck   []Entry `json:"deck"`
+	Relic  string  `json:"relic,omitempty"`
+	// Color of the cards offered to the class, e.g. by the rewards and shops
+	Color string `json:"color,omitempty"`
+}
+
+// Pool of the cards offered to the class
+func (def *Def) Pool() library.Pool {
+	return library.Pool{Color: def.Color}
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the class, the cards and the relic must be defined first
+func Define(def *Def) error {
+	if def.ID == "" || def.HP == 0 || def.Gold < 0 || def.Energy < 0 {
+		return ErrInvalidDef
+	}
+
+	for _, e := range def.Deck {
+		if e.Count <= 0 {
+			return ErrInvalidDef
+		}
+		if _, err := library.Get(e.Card); err != nil {
+			return err
+		}
+	}
+
+	if def.Relic != "" {
+		if _, err := relics.Get(def.Relic); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+// Of returns the class def of the player, nil if the player is classless
+func Of(state *store.State) *Def {
+	def, _ := Get(state.Player().Class)
+	return def
+}
+
+// Pool of the cards offered to the player, any color if the player is classless
+func Pool(state *store.State) library.Pool {
+	if def := Of(state); def != nil {
+		return def.Pool()
+	}
+	return library.Pool{}
+}
+
+// New run of the class, the player starts with the stats and the starter deck of the class,
+// the starter relic is obtained when the run is started, see Start
+func New(class string, id string, seed int64) (*run.Run, error) {
+	def, err := Get(class)
+	if err != nil {
+		return nil, err
+	}
+
+	var deck cards.Pile
+	for _, e := range def.Deck {
+		for i := 0; i < e.Count; i++ {
+			c, err := library.New(e.Card)
+			if err != nil {
+				return nil, err
+			}
+			deck = append(deck, c)
+		}
+	}
+
+	r := run.New(id, seed)
+	err = r.State.Update(func(tx *store.Tx) error {
+		p := tx.Player()
+		p.SetID("player")
+		p.HP, p.MaxHP = def.HP, def.HP
+		p.Class = def.ID
+		p.Energy = def.Energy
+
+		tx.SetGold(def.Gold)
+		tx.SetPile(store.Deck, deck)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start action attaches the new run to the chain, then the player obtains the starter relic of the class.
+// The restored run is attached by run.Attach instead, its relics are obtained already.
+type Start struct {
+	Run *run.Run
+	// Saver writes the run at the safe points of the chain, optional
+	Saver *run.Saver
+}
+
+// Exec -
+func (a *Start) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	next := []actions.Action{&run.Attach{Run: a.Run, Saver: a.Saver}}
+	if def := Of(a.Run.State); def != nil && def.Relic != "" {
+		next = append(next, &relics.Obtain{ID: def.Relic})
+	}
+	return next, nil
+}
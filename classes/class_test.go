@@ -0,0 +1,125 @@
+package classes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "cls_ironclad", "name": "Ironclad", "hp": 80, "gold": 99, "color": "red", "relic": "cls_burning_blood",
+		"deck": [{"card": "cls_strike", "count": 5}, {"card": "cls_defend", "count": 4}, {"card": "cls_bash", "count": 1}]},
+	{"id": "cls_defect", "name": "Defect", "hp": 75, "energy": 4, "color": "blue",
+		"deck": [{"card": "cls_strike", "count": 1}]}
+]`
+
+func init() {
+	for _, id := range []string{"cls_strike", "cls_defend", "cls_bash"} {
+		library.Define(&library.Def{ID: id, Name: id, Type: library.Attack, Rarity: library.Basic, Color: "red"})
+	}
+	relics.Define(&relics.Def{ID: "cls_burning_blood", Rarity: library.Basic})
+
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "cls_defect", HP: 1}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "cls_x"}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "cls_x", HP: 1, Energy: -1}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "cls_x", HP: 1, Deck: []Entry{{Card: "cls_strike"}}}))
+	assert.Equal(t, library.ErrNotDefined, Define(&Def{ID: "cls_x", HP: 1, Deck: []Entry{{Card: "x", Count: 1}}}))
+	assert.Equal(t, relics.ErrNotDefined, Define(&Def{ID: "cls_x", HP: 1, Relic: "x"}))
+
+	_, err := Get("cls_x")
+	assert.Equal(t, ErrNotDefined, err)
+	assert.Equal(t, 2, len(Defs()))
+	assert.Equal(t, "cls_defect", Defs()[0].ID)
+}
+
+func TestNew(t *testing.T) {
+	r, err := New("cls_ironclad", "run", 42)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), r.Seed)
+
+	p := r.State.Player()
+	assert.Equal(t, "player", p.ID())
+	assert.Equal(t, uint(80), p.HP)
+	assert.Equal(t, uint(80), p.MaxHP)
+	assert.Equal(t, "cls_ironclad", p.Class)
+	assert.Equal(t, 0, p.Energy)
+	assert.Equal(t, 99, r.State.Gold())
+	assert.Nil(t, r.State.Relics())
+
+	// the starter deck in order, every card has its own id
+	deck := r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 10)
+	assert.Equal(t, "cls_strike", deck[0].Type())
+	assert.Equal(t, "cls_defend", deck[5].Type())
+	assert.Equal(t, "cls_bash", deck[9].Type())
+	assert.NotEqual(t, deck[0].ID(), deck[1].ID())
+
+	assert.Equal(t, library.Pool{Color: "red"}, Pool(r.State))
+	assert.Equal(t, "cls_ironclad", Of(r.State).ID)
+
+	r, err = New("cls_defect", "run", 42)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, r.State.Player().Energy)
+	assert.Nil(t, r.State.Relics())
+
+	_, err = New("cls_x", "run", 42)
+	assert.Equal(t, ErrNotDefined, err)
+
+	// the classless player is offered any color
+	assert.Nil(t, Of(&store.State{}))
+	assert.Equal(t, library.Pool{}, Pool(&store.State{}))
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStart(t *testing.T) {
+	r, err := New("cls_ironclad", "run", 42)
+	assert.Nil(t, err)
+
+	obtained := 0
+	defer hooks.Listen(func(c *actions.Context, ev *hooks.Event) []actions.Action {
+		if ev.Hook == hooks.RelicObtained && ev.Relic == "cls_burning_blood" {
+			obtained++
+		}
+		return nil
+	})()
+
+	// the starter relic is obtained through the obtain path, with its event and hook
+	outc := make(chan []byte, 8)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &Start{Run: r}))
+	assert.Equal(t, r, run.Of(ctx))
+	assert.Equal(t, []store.Relic{{ID: "cls_burning_blood"}}, r.State.Relics())
+	assert.Equal(t, 1, obtained)
+	assert.Len(t, outc, 1)
+
+	// the class without a starter relic
+	r, _ = New("cls_defect", "run", 42)
+	ctx = actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &Start{Run: r}))
+	assert.Nil(t, r.State.Relics())
+}
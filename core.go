@@ -33,22 +33,33 @@ func Start(action actions.Action, state *store.State) (<-chan error, chan<- acti
 
 // chain action execution
 func exec(ctx *actions.Context, action actions.Action) error {
-	// TODO: context and action validation
-	if action != nil {
-		next, err := action.Exec(ctx)
-		if err != nil {
+	for {
+		if err := run(ctx, action); err != nil {
 			return err
 		}
+		// when previous action and all its next actions are done,
+		// waitForInput will be automatically added into the execution chain
+		action = &actions.WaitForInput{}
+	}
+}
 
-		for _, action := range next {
-			err = exec(ctx, action)
-			// if the error is not nil, break the loop and return
-			if err != nil {
-				return err
-			}
+// run the action, and its next actions one by one
+func run(ctx *actions.Context, action actions.Action) error {
+	// TODO: context and action validation
+	if action == nil {
+		return nil
+	}
+
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range next {
+		// if the error is not nil, break the loop and return
+		if err := run(ctx, action); err != nil {
+			return err
 		}
 	}
-	// when previous action return is nil,
-	// waitForInput will be automatically added into the execution chain
-	return exec(ctx, &actions.WaitForInput{})
+	return nil
 }
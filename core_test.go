@@ -112,3 +112,23 @@ sender: // continuously sending data to execution
 
 	close(inputc)
 }
+
+func TestChainWithNextActions(t *testing.T) {
+	state := &store.State{}
+
+	// all the next actions are executed before waiting for the input
+	first := actions.ActionFunc(func(ctx *actions.Context) ([]actions.Action, error) {
+		return []actions.Action{&update{delta: 1}, &update{delta: 2}, &update{delta: 3}}, nil
+	})
+
+	errc, inputc, outputc := Start(first, state)
+
+	var nums []uint32
+	for i := 0; i < 3; i++ {
+		nums = append(nums, binary.LittleEndian.Uint32(<-outputc))
+	}
+	assert.Equal(t, []uint32{1, 3, 6}, nums)
+
+	close(inputc)
+	assert.Equal(t, actions.ErrCanceled, <-errc)
+}
@@ -0,0 +1,257 @@
+package dungeon
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/sleep2death/hexcore/hex"
+)
+
+// ErrInvalidConfig -
+var ErrInvalidConfig = errors.New("invalid map config")
+
+// NodeType of the map node
+type NodeType string
+
+const (
+	// Battle node
+	Battle NodeType = "battle"
+	// Elite node
+	Elite NodeType = "elite"
+	// Event node, the "?" room
+	Event NodeType = "event"
+	// Shop node
+	Shop NodeType = "shop"
+	// Rest node
+	Rest NodeType = "rest"
+	// Treasure node
+	Treasure NodeType = "treasure"
+	// Boss node, the last node of the act
+	Boss NodeType = "boss"
+)
+
+// types chosen randomly, in the order of the rolls
+var randomTypes = []NodeType{Battle, Event, Elite, Rest, Shop}
+
+// Config of the map generator
+type Config struct {
+	// Width is the number of the hex columns
+	Width int
+	// Floors before the boss, the first floor is always battle, the last one is always rest
+	Floors int
+	// Paths walked from the first floor to the last one, the nodes are on the paths
+	Paths int
+	// TreasureFloor is always treasure
+	TreasureFloor int
+	// Weights of the random node types
+	Weights map[NodeType]int
+	// MinFloor of the random node types, e.g. no elites in the first floors
+	MinFloor map[NodeType]int
+}
+
+// DefaultConfig of the map generator
+var DefaultConfig = Config{
+	Width:         7,
+	Floors:        15,
+	Paths:         6,
+	TreasureFloor: 8,
+	Weights:       map[NodeType]int{Battle: 45, Event: 22, Elite: 16, Rest: 12, Shop: 5},
+	MinFloor:      map[NodeType]int{Elite: 5, Rest: 5},
+}
+
+// Node of the map
+type Node struct {
+	ID    string    `json:"id"`
+	Coord hex.Coord `json:"coord"`
+	Floor int       `json:"floor"`
+	Type  NodeType  `json:"type"`
+	// Next nodes reachable from the node
+	Next []string `json:"next,omitempty"`
+
+	prev []*Node
+}
+
+// Map of the act on the hex grid, every floor is a row of the hexes,
+// and the node leads to the hexes next to it on the next row
+type Map struct {
+	Act   int     `json:"act"`
+	Nodes []*Node `json:"nodes"`
+
+	index map[string]*Node
+}
+
+// Validate the config, at least one column and one path,
+// and two floors for the first battle and the last rest
+func (cfg *Config) Validate() error {
+	if cfg.Width <= 0 || cfg.Floors < 2 || cfg.Paths <= 0 {
+		return ErrInvalidConfig
+	}
+	if cfg.TreasureFloor < 0 || cfg.TreasureFloor >= cfg.Floors {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+// Generate the map of the act, the same seed always generates the same map
+func Generate(seed int64, act int, cfg Config) (*Map, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	m := &Map{Act: act, index: make(map[string]*Node)}
+
+	node := func(c hex.Coord, floor int) *Node {
+		n, ok := m.index[c.String()]
+		if !ok {
+			n = &Node{ID: c.String(), Coord: c, Floor: floor}
+			m.index[n.ID] = n
+			m.Nodes = append(m.Nodes, n)
+		}
+		return n
+	}
+
+	link := func(from, to *Node) {
+		for _, id := range from.Next {
+			if id == to.ID {
+				return
+			}
+		}
+		from.Next = append(from.Next, to.ID)
+		to.prev = append(to.prev, from)
+	}
+
+	// walk the paths
+	for p := 0; p < cfg.Paths; p++ {
+		col := r.Intn(cfg.Width)
+		cur := node(hex.FromOffset(col, 0), 0)
+
+		for floor := 1; floor < cfg.Floors; floor++ {
+			// the hexes next to the current one on the next row
+			var cols []int
+			for _, c := range cur.Coord.Neighbors() {
+				if nc, row := c.Offset(); row == floor && nc >= 0 && nc < cfg.Width {
+					cols = append(cols, nc)
+				}
+			}
+			sort.Ints(cols)
+
+			next := node(hex.FromOffset(cols[r.Intn(len(cols))], floor), floor)
+			link(cur, next)
+			cur = next
+		}
+	}
+
+	boss := node(hex.FromOffset(cfg.Width/2, cfg.Floors), cfg.Floors)
+	boss.Type = Boss
+
+	sort.Slice(m.Nodes, func(i, j int) bool {
+		a, b := m.Nodes[i].Coord, m.Nodes[j].Coord
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		return a.Q < b.Q
+	})
+
+	for _, n := range m.Nodes {
+		if n.Floor == cfg.Floors-1 {
+			link(n, boss)
+		}
+		sort.Strings(n.Next)
+	}
+
+	for _, n := range m.Nodes {
+		if n.Type == "" {
+			n.Type = cfg.roll(r, n)
+		}
+	}
+	return m, nil
+}
+
+// roll the type of the node, the nodes are rolled floor by floor,
+// so the types of the previous nodes are known
+func (cfg *Config) roll(r *rand.Rand, n *Node) NodeType {
+	switch n.Floor {
+	case 0:
+		return Battle
+	case cfg.TreasureFloor:
+		return Treasure
+	case cfg.Floors - 1:
+		return Rest
+	}
+
+	excluded := make(map[NodeType]bool)
+	// no consecutive elites or rests
+	for _, p := range n.prev {
+		if p.Type == Elite || p.Type == Rest {
+			excluded[p.Type] = true
+		}
+	}
+	// the last floor is rest
+	if n.Floor == cfg.Floors-2 {
+		excluded[Rest] = true
+	}
+
+	total := 0
+	var candidates []NodeType
+	for _, t := range randomTypes {
+		if excluded[t] || n.Floor < cfg.MinFloor[t] || cfg.Weights[t] <= 0 {
+			continue
+		}
+		candidates = append(candidates, t)
+		total += cfg.Weights[t]
+	}
+
+	if total == 0 {
+		return Battle
+	}
+
+	i := r.Intn(total)
+	for _, t := range candidates {
+		if i -= cfg.Weights[t]; i < 0 {
+			return t
+		}
+	}
+	return Battle
+}
+
+// Node by id
+func (m *Map) Node(id string) (*Node, bool) {
+	n, ok := m.index[id]
+	return n, ok
+}
+
+// Start nodes of the map, on the first floor
+func (m *Map) Start() []*Node {
+	var ns []*Node
+	for _, n := range m.Nodes {
+		if n.Floor == 0 {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// Boss node of the map
+func (m *Map) Boss() *Node {
+	return m.Nodes[len(m.Nodes)-1]
+}
+
+// Reachable nodes from the node, the start nodes if the id is empty
+func (m *Map) Reachable(from string) []*Node {
+	if from == "" {
+		return m.Start()
+	}
+
+	n, ok := m.index[from]
+	if !ok {
+		return nil
+	}
+
+	ns := make([]*Node, 0, len(n.Next))
+	for _, id := range n.Next {
+		ns = append(ns, m.index[id])
+	}
+	return ns
+}
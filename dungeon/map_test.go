@@ -0,0 +1,99 @@
+package dungeon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	cfg := DefaultConfig
+
+	for seed := int64(0); seed < 50; seed++ {
+		m, err := Generate(seed, 1, cfg)
+		assert.Nil(t, err)
+
+		// deterministic
+		again, _ := Generate(seed, 1, cfg)
+		assert.Equal(t, m, again)
+
+		boss := m.Boss()
+		assert.Equal(t, Boss, boss.Type)
+		assert.Equal(t, cfg.Floors, boss.Floor)
+		assert.Nil(t, boss.Next)
+
+		assert.NotEmpty(t, m.Start())
+		for _, n := range m.Nodes {
+			switch n.Floor {
+			case 0:
+				assert.Equal(t, Battle, n.Type)
+			case cfg.TreasureFloor:
+				assert.Equal(t, Treasure, n.Type)
+			case cfg.Floors - 1:
+				assert.Equal(t, Rest, n.Type)
+				assert.Equal(t, []string{boss.ID}, n.Next)
+			}
+
+			if n.Floor < cfg.MinFloor[Elite] {
+				assert.NotEqual(t, Elite, n.Type)
+			}
+
+			for _, next := range m.Reachable(n.ID) {
+				assert.Equal(t, n.Floor+1, next.Floor)
+
+				// no consecutive elites or rests
+				if n.Type == Elite || n.Type == Rest {
+					assert.NotEqual(t, n.Type, next.Type, "seed %d: %s -> %s", seed, n.ID, next.ID)
+				}
+
+				// the next node is next to the node, except the boss
+				if next.Type != Boss {
+					assert.Equal(t, 1, n.Coord.Distance(next.Coord))
+				}
+			}
+		}
+
+		// every node can be reached from the start, and leads to the boss
+		reached := make(map[string]bool)
+		var walk func(ns []*Node)
+		walk = func(ns []*Node) {
+			for _, n := range ns {
+				if !reached[n.ID] {
+					reached[n.ID] = true
+					walk(m.Reachable(n.ID))
+				}
+			}
+		}
+		walk(m.Start())
+		assert.Equal(t, len(m.Nodes), len(reached))
+	}
+
+	m1, _ := Generate(1, 1, cfg)
+	m2, _ := Generate(2, 1, cfg)
+	assert.NotEqual(t, m1, m2)
+	assert.Nil(t, m1.Reachable("x"))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, invalid := range []func(cfg *Config){
+		func(cfg *Config) { cfg.Width = 0 },
+		func(cfg *Config) { cfg.Floors = 1 },
+		func(cfg *Config) { cfg.Paths = 0 },
+		func(cfg *Config) { cfg.TreasureFloor = -1 },
+		func(cfg *Config) { cfg.TreasureFloor = cfg.Floors },
+	} {
+		cfg := DefaultConfig
+		invalid(&cfg)
+		m, err := Generate(1, 1, cfg)
+		assert.Equal(t, ErrInvalidConfig, err)
+		assert.Nil(t, m)
+	}
+
+	// the smallest map
+	cfg := DefaultConfig
+	cfg.Width, cfg.Floors, cfg.Paths, cfg.TreasureFloor = 1, 2, 1, 0
+	m, err := Generate(1, 1, cfg)
+	assert.Nil(t, err)
+	assert.Len(t, m.Nodes, 3)
+	assert.Equal(t, Battle, m.Start()[0].Type)
+}
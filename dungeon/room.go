@@ -0,0 +1,297 @@
+package dungeon
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/rest"
+	"github.com/sleep2death/hexcore/rewards"
+	"github.com/sleep2death/hexcore/rng"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/shop"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrUnreachable -
+	ErrUnreachable = errors.New("node is not reachable")
+	// ErrInBattle -
+	ErrInBattle = errors.New("can't leave the battle")
+)
+
+// Acts of the run, the run is over after the boss of the last act
+const Acts = 3
+
+// PendingRoom is the kind of the run pending, when the room of the node is entered but not completed
+const PendingRoom = "room"
+
+// EliteHP is the max HP of the elite monsters spawned, modified by the run modifiers
+const EliteHP = "elite_hp"
+
+// Room starts the encounter chain of the node
+type Room func(ctx *actions.Context, r *run.Run, node *Node) ([]actions.Action, error)
+
+var (
+	roomsMu sync.RWMutex
+	rooms   = make(map[NodeType]Room)
+)
+
+// Register the room of the node type, it replaces the previous one.
+// The nodes without a room are empty.
+func Register(t NodeType, room Room) {
+	roomsMu.Lock()
+	rooms[t] = room
+	roomsMu.Unlock()
+}
+
+func init() {
+	Register(Battle, fight(monsters.Normal))
+	Register(Elite, fight(monsters.Elite))
+	Register(Boss, fight(monsters.Boss))
+	Register(Shop, func(ctx *actions.Context, r *run.Run, node *Node) ([]actions.Action, error) {
+		return []actions.Action{&shop.Enter{}}, nil
+	})
+	Register(Rest, func(ctx *actions.Context, r *run.Run, node *Node) ([]actions.Action, error) {
+		return []actions.Action{&rest.Enter{}}, nil
+	})
+	Register(Treasure, treasure)
+
+	hooks.Listen(reward)
+	run.OnResume(PendingRoom, func(ctx *actions.Context, r *run.Run, p *run.Pending) ([]actions.Action, error) {
+		return []actions.Action{&Enter{}}, nil
+	})
+}
+
+// fight room of the kind
+func fight(kind monsters.Kind) Room {
+	return func(ctx *actions.Context, r *run.Run, node *Node) ([]actions.Action, error) {
+		return []actions.Action{&Fight{Kind: kind}}, nil
+	}
+}
+
+// treasure room opens the chest, which holds a random relic not owned yet,
+// the chest is empty if there is none left
+func treasure(ctx *actions.Context, r *run.Run, node *Node) ([]actions.Action, error) {
+	id := rewards.RandomRelic(r, r.Rand("treasure"))
+	next := []actions.Action{&actions.Emit{Event: "treasure", Data: id}}
+	if id == "" {
+		return next, nil
+	}
+	return append(next, &relics.Obtain{ID: id}), nil
+}
+
+// key of the kind of the current fight in the chain context
+type fightKey struct{}
+
+// Fight action starts the battle with a random encounter of the kind,
+// and the reward of the kind is offered after the victory
+type Fight struct {
+	Kind monsters.Kind
+	// Encounter id to fight instead of the random one, e.g. the fight of the event
+	Encounter string
+}
+
+// Exec -
+func (a *Fight) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	var enc *monsters.Encounter
+	var err error
+	if a.Encounter != "" {
+		enc, err = monsters.GetEncounter(a.Encounter)
+	} else {
+		enc, err = monsters.Pick(r.Rand("encounters"), r.Position.Act, a.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ms, err := monsters.Spawn(r.Rand("monsters"), enc)
+	if err != nil {
+		return nil, err
+	}
+
+	if enc.Kind == monsters.Elite {
+		for i := range ms {
+			if hp := r.Value(EliteHP, int(ms[i].MaxHP)); hp > 0 {
+				ms[i].MaxHP, ms[i].HP = uint(hp), uint(hp)
+			}
+		}
+	}
+
+	ctx.SetValue(fightKey{}, a.Kind)
+	return []actions.Action{&battle.Start{Monsters: ms}}, nil
+}
+
+// reward of the fight when the battle is over
+func reward(ctx *actions.Context, ev *hooks.Event) []actions.Action {
+	if ev.Hook != hooks.BattleEnd {
+		return nil
+	}
+
+	kind, ok := ctx.Value(fightKey{}).(monsters.Kind)
+	if !ok {
+		return nil
+	}
+
+	ctx.SetValue(fightKey{}, nil)
+	return []actions.Action{&rewards.Offer{Kind: kind}}
+}
+
+// Of returns the map of the act of the run
+func Of(r *run.Run, act int) (*Map, error) {
+	return Generate(rng.Derive(r.Seed, "map"+strconv.Itoa(act)), act, DefaultConfig)
+}
+
+// Reachable nodes of the run, the start nodes of the next act after the boss,
+// returns the act of the nodes too
+func Reachable(r *run.Run) (int, []*Node, error) {
+	act := r.Position.Act
+	m, err := Of(r, act)
+	if err != nil {
+		return act, nil, err
+	}
+
+	if n, ok := m.Node(r.Position.Node); ok && n.Type == Boss {
+		if act >= Acts {
+			return act, nil, nil
+		}
+		act++
+		if m, err = Of(r, act); err != nil {
+			return act, nil, err
+		}
+		return act, m.Start(), nil
+	}
+	return act, m.Reachable(r.Position.Node), nil
+}
+
+// ChooseNode input action moves the player to the reachable node,
+// and starts the room of the node. The run is saved with the room pending before the room is started,
+// so the resumed run enters the same room again
+type ChooseNode struct {
+	Node string
+}
+
+// Exec -
+func (a *ChooseNode) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	for _, m := range store.GetStore().State(ctx.ID()).Monsters() {
+		if !m.Dead() {
+			return actions.Reject("choose_node", ErrInBattle), nil
+		}
+	}
+
+	if prompt.Pending(ctx) != nil {
+		return actions.Reject("choose_node", prompt.ErrPending), nil
+	}
+
+	act, nodes, err := Reachable(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var node *Node
+	for _, n := range nodes {
+		if n.ID == a.Node {
+			node = n
+		}
+	}
+	if node == nil {
+		return actions.Reject("choose_node", ErrUnreachable), nil
+	}
+
+	if act != r.Position.Act {
+		r.Position = run.Position{Act: act}
+	}
+	r.Position.Floor = node.Floor
+	r.Position.Node = node.ID
+
+	return []actions.Action{&run.SafePoint{Pending: &run.Pending{Kind: PendingRoom}}, &Enter{}}, nil
+}
+
+// Enter action sends the node of the position to the output, and starts the room of the node
+type Enter struct {
+}
+
+// Exec -
+func (a *Enter) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	m, err := Of(r, r.Position.Act)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := m.Node(r.Position.Node)
+	if !ok {
+		return nil, ErrUnreachable
+	}
+	next := []actions.Action{&actions.Emit{Event: "node", Data: node}}
+
+	roomsMu.RLock()
+	room, ok := rooms[node.Type]
+	roomsMu.RUnlock()
+
+	if !ok {
+		return next, nil
+	}
+
+	encounter, err := room(ctx, r, node)
+	if err != nil {
+		return nil, err
+	}
+	return append(next, encounter...), nil
+}
+
+// ShowMap action sends the map of the current act,
+// and the reachable nodes to the output
+type ShowMap struct {
+}
+
+// MapData sent to the output
+type MapData struct {
+	*Map
+	Position  run.Position `json:"position"`
+	Reachable []string     `json:"reachable"`
+}
+
+// Exec -
+func (a *ShowMap) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	act, nodes, err := Reachable(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := Of(r, act)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &MapData{Map: m, Position: r.Position, Reachable: make([]string, 0, len(nodes))}
+	for _, n := range nodes {
+		data.Reachable = append(data.Reachable, n.ID)
+	}
+	return []actions.Action{&actions.Emit{Event: "map", Data: data}}, nil
+}
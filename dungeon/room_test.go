@@ -0,0 +1,355 @@
+package dungeon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/router"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	monsters.Define(&monsters.Def{ID: "dun_slime", MinHP: 10, MaxHP: 10, Moves: []monsters.Move{
+		{ID: "tackle", Effects: []effects.Effect{{Op: effects.Damage, Amount: 3}}},
+	}})
+	for act := 1; act <= Acts; act++ {
+		monsters.DefineEncounter(&monsters.Encounter{ID: "dun_slime" + string(rune('0'+act)),
+			Kind: monsters.Normal, Act: act, Monsters: []string{"dun_slime"}})
+	}
+	monsters.DefineEncounter(&monsters.Encounter{ID: "dun_elite", Kind: monsters.Elite, Act: 1, Monsters: []string{"dun_slime"}})
+	relics.Define(&relics.Def{ID: "dun_chest", Name: "Chest", Rarity: library.Common})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func newRun(t *testing.T) (*actions.Context, *run.Run, chan []byte) {
+	r := run.New("dungeon", 7)
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 80
+		tx.Player().MaxHP = 80
+		return nil
+	})
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+	assert.Equal(t, r, run.Of(ctx))
+	return ctx, r, outc
+}
+
+func mapOf(t *testing.T, r *run.Run, act int) *Map {
+	m, err := Of(r, act)
+	assert.Nil(t, err)
+	return m
+}
+
+func TestChooseNode(t *testing.T) {
+	ctx, r, outc := newRun(t)
+
+	// no run attached
+	other := actions.NewContext(nil, outc, store.GetStore().AddState(&store.State{}))
+	assert.Equal(t, run.ErrNoRun, execute(other, &ChooseNode{}))
+	assert.Equal(t, run.ErrStateMismatch, execute(other, &run.Attach{Run: r}))
+
+	m := mapOf(t, r, 1)
+	act, nodes, err := Reachable(r)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, act)
+	assert.Equal(t, m.Start(), nodes)
+
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: m.Boss().ID}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	// the battle is started
+	start := nodes[0]
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.ID}))
+	assert.Equal(t, []string{"node", "turn_start"}, events(outc))
+	assert.Equal(t, run.Position{Act: 1, Node: start.ID}, r.Position)
+	assert.Equal(t, "dun_slime-0", r.State.Monsters()[0].ID())
+
+	// can't leave the battle
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.Next[0]}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetMonsters(nil)
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.Next[0]}))
+	assert.Equal(t, 1, r.Position.Floor)
+	events(outc)
+
+	// after the boss, the next act starts
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetMonsters(nil)
+		return nil
+	})
+	r.Position.Node = m.Boss().ID
+	act, nodes, err = Reachable(r)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, act)
+	assert.Equal(t, mapOf(t, r, 2).Start(), nodes)
+
+	// an empty room
+	defer Register(Battle, fight(monsters.Normal))
+	roomsMu.Lock()
+	delete(rooms, Battle)
+	roomsMu.Unlock()
+
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: nodes[0].ID}))
+	assert.Equal(t, []string{"node"}, events(outc))
+	assert.Equal(t, run.Position{Act: 2, Node: nodes[0].ID}, r.Position)
+
+	// the run is over after the last boss
+	r.Position = run.Position{Act: Acts, Node: mapOf(t, r, Acts).Boss().ID}
+	_, nodes, err = Reachable(r)
+	assert.Nil(t, err)
+	assert.Nil(t, nodes)
+
+	// the invalid config
+	cfg := DefaultConfig
+	defer func() { DefaultConfig = cfg }()
+	DefaultConfig.Floors = 1
+
+	_, _, err = Reachable(r)
+	assert.Equal(t, ErrInvalidConfig, err)
+	assert.Equal(t, ErrInvalidConfig, execute(ctx, &ChooseNode{}))
+	assert.Equal(t, ErrInvalidConfig, execute(ctx, &ShowMap{}))
+}
+
+func TestFightReward(t *testing.T) {
+	ctx, r, outc := newRun(t)
+
+	start := mapOf(t, r, 1).Start()[0]
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.ID}))
+	events(outc)
+
+	// the reward screen follows the victory
+	err := execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 100}}, Target: "dun_slime-0"})
+	assert.Nil(t, err)
+	assert.Nil(t, execute(ctx, &battle.Check{}))
+	assert.Equal(t, []string{"victory", "prompt"}, events(outc))
+	assert.Equal(t, "reward", prompt.Pending(ctx).Kind)
+
+	// can't leave before the reward is done
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.Next[0]}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	// the screen is done when everything is taken, no card is defined here
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "gold"}))
+	assert.True(t, r.State.Gold() >= 10)
+	assert.Equal(t, []string{"gold_obtained", "reward_done"}, events(outc))
+	assert.Nil(t, prompt.Pending(ctx))
+
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.Next[0]}))
+	assert.Equal(t, 1, r.Position.Floor)
+}
+
+func TestSafePoints(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	ctx, r, outc := newRun(t)
+	saver := &run.Saver{Dir: dir, Key: []byte("secret")}
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r, Saver: saver}))
+
+	// saved when entering the room
+	start := mapOf(t, r, 1).Start()[0]
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.ID}))
+	events(outc)
+	saved, err := saver.Read(r.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, run.Position{Act: 1, Node: start.ID}, saved.Position)
+
+	// and when the reward screen is shown
+	assert.Nil(t, saver.Remove(r.ID))
+	err = execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 100}}, Target: "dun_slime-0"})
+	assert.Nil(t, err)
+	assert.Nil(t, execute(ctx, &battle.Check{}))
+	assert.Equal(t, []string{"victory", "prompt"}, events(outc))
+	saved, err = saver.Read(r.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, r.State.Player().HP, saved.State.Player().HP)
+}
+
+// reload the saved run into a new chain, and resume it
+func reload(t *testing.T, saver *run.Saver, id string) (*actions.Context, *run.Run, chan []byte) {
+	r, err := saver.Read(id)
+	assert.Nil(t, err)
+
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r, Saver: saver}))
+	assert.Nil(t, execute(ctx, &run.Resume{}))
+	return ctx, r, outc
+}
+
+func TestResume(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	ctx, r, outc := newRun(t)
+	saver := &run.Saver{Dir: dir, Key: []byte("secret")}
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r, Saver: saver}))
+
+	// quit in the middle of the fight, the same fight starts again
+	start := mapOf(t, r, 1).Start()[0]
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: start.ID}))
+	events(outc)
+	monster := r.State.Monsters()[0]
+	err := execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 5}}, Target: monster.ID()})
+	assert.Nil(t, err)
+
+	ctx, l, outc := reload(t, saver, r.ID)
+	assert.Equal(t, []string{"node", "turn_start"}, events(outc))
+	assert.Equal(t, r.Position, l.Position)
+	assert.Equal(t, monster, l.State.Monsters()[0])
+
+	// quit at the reward screen, the same reward is offered again
+	err = execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 100}}, Target: monster.ID()})
+	assert.Nil(t, err)
+	assert.Nil(t, execute(ctx, &battle.Check{}))
+	assert.Equal(t, []string{"victory", "prompt"}, events(outc))
+	offered := prompt.Pending(ctx).Data
+
+	ctx, l, outc = reload(t, saver, r.ID)
+	assert.Equal(t, []string{"prompt"}, events(outc))
+	assert.Equal(t, "reward", prompt.Pending(ctx).Kind)
+	assert.Equal(t, offered, prompt.Pending(ctx).Data)
+	assert.Equal(t, start.ID, l.Position.Node)
+}
+
+func TestResumeTreasure(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	ctx, r, outc := newRun(t)
+	saver := &run.Saver{Dir: dir, Key: []byte("secret")}
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r, Saver: saver}))
+
+	var from *Node
+	for _, n := range mapOf(t, r, 1).Nodes {
+		if n.Floor == DefaultConfig.TreasureFloor-1 {
+			from = n
+		}
+	}
+	r.Position = run.Position{Act: 1, Floor: from.Floor, Node: from.ID}
+
+	// quit after the chest is opened, it's opened again with the same relic
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: from.Next[0]}))
+	assert.Equal(t, []string{"node", "treasure", "relic_obtained"}, events(outc))
+
+	_, l, outc := reload(t, saver, r.ID)
+	assert.Equal(t, []string{"node", "treasure", "relic_obtained"}, events(outc))
+	assert.Equal(t, r.State.Relics(), l.State.Relics())
+}
+
+func TestTreasure(t *testing.T) {
+	ctx, r, outc := newRun(t)
+
+	// every node before the treasure floor leads to the treasure
+	var from *Node
+	for _, n := range mapOf(t, r, 1).Nodes {
+		if n.Floor == DefaultConfig.TreasureFloor-1 {
+			from = n
+		}
+	}
+	r.Position = run.Position{Act: 1, Floor: from.Floor, Node: from.ID}
+
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: from.Next[0]}))
+	assert.Equal(t, []string{"node", "treasure", "relic_obtained"}, events(outc))
+	assert.Equal(t, []store.Relic{{ID: "dun_chest"}}, r.State.Relics())
+
+	// the chest is empty, when all the relics are owned
+	r.Position = run.Position{Act: 1, Floor: from.Floor, Node: from.ID}
+	assert.Nil(t, execute(ctx, &ChooseNode{Node: from.Next[0]}))
+	assert.Equal(t, []string{"node", "treasure"}, events(outc))
+}
+
+func TestEliteHP(t *testing.T) {
+	ctx, r, _ := newRun(t)
+
+	defer run.Modify(func(r *run.Run, name string, value int) int {
+		if name == EliteHP {
+			return value * 2
+		}
+		return value
+	})()
+
+	assert.Nil(t, execute(ctx, &Fight{Kind: monsters.Elite, Encounter: "dun_slime1"}))
+	assert.Equal(t, uint(10), r.State.Monsters()[0].MaxHP)
+
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetMonsters(nil)
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &Fight{Kind: monsters.Elite, Encounter: "dun_elite"}))
+	m := r.State.Monsters()[0]
+	assert.Equal(t, uint(20), m.MaxHP)
+	assert.Equal(t, uint(20), m.HP)
+}
+
+func TestShowMap(t *testing.T) {
+	ctx, r, outc := newRun(t)
+	assert.Nil(t, execute(ctx, &ShowMap{}))
+
+	data := &struct {
+		Data struct {
+			Act       int
+			Nodes     []*Node
+			Reachable []string
+		}
+	}{}
+	assert.Nil(t, json.Unmarshal(<-outc, data))
+	assert.Equal(t, 1, data.Data.Act)
+	assert.Equal(t, len(mapOf(t, r, 1).Nodes), len(data.Data.Nodes))
+	assert.Equal(t, len(mapOf(t, r, 1).Start()), len(data.Data.Reachable))
+}
+
+func TestRoute(t *testing.T) {
+	r := router.New()
+	Route(r)
+
+	action, err := r.Serve(&router.Request{Type: router.Normal, Path: "/map/choose/3:-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, &ChooseNode{Node: "3:-1"}, action)
+
+	action, err = r.Serve(&router.Request{Type: router.Normal, Path: "/map"})
+	assert.Nil(t, err)
+	assert.Equal(t, &ShowMap{}, action)
+}
@@ -0,0 +1,26 @@
+package dungeon
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/router"
+)
+
+// Route the map input actions
+func Route(r *router.Router) {
+	r.Handle(router.Normal, "/map", showMap,
+		router.WithDoc("show the map of the current act"))
+	r.Handle(router.Normal, "/map/choose/:node<id>", chooseNode,
+		router.WithDoc("move to the reachable node"))
+}
+
+func showMap(req *router.Request, ps router.Params) (actions.Action, error) {
+	return &ShowMap{}, nil
+}
+
+func chooseNode(req *router.Request, ps router.Params) (actions.Action, error) {
+	node, err := ps.ID("node")
+	if err != nil {
+		return nil, err
+	}
+	return &ChooseNode{Node: node}, nil
+}
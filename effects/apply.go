@@ -0,0 +1,356 @@
+package effects
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/hex"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// HandLimit is the max number of cards in hand, the extra draws are ignored
+const HandLimit = 10
+
+// Apply action applies the effects to the state in one transaction,
+// the hooks of the events caused by them, e.g. damage taken, are triggered after it
+type Apply struct {
+	Effects []Effect
+	// Source actor id of the effects, the player if it's empty
+	Source string
+	// Target actor id chosen by the player
+	Target string
+	// Hex chosen by the player
+	Hex *hex.Coord
+}
+
+// Exec -
+func (a *Apply) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var events []*hooks.Event
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		for _, e := range a.Effects {
+			evs, err := a.apply(tx, e)
+			if err != nil {
+				return err
+			}
+			events = append(events, evs...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	next := make([]actions.Action, 0, len(events))
+	for _, ev := range events {
+		next = append(next, &hooks.Trigger{Event: *ev})
+	}
+	return next, nil
+}
+
+func (a *Apply) apply(tx *store.Tx, e Effect) ([]*hooks.Event, error) {
+	if e.Scale != "" {
+		e.Amount *= tx.Count(e.Scale)
+	}
+
+	switch e.Op {
+	case GainEnergy:
+		tx.SetEnergy(tx.Energy() + e.Amount)
+		return nil, nil
+	case GainGold:
+		tx.SetGold(tx.Gold() + e.Amount)
+		return nil, nil
+	case LoseGold:
+		if e.Amount > tx.Gold() {
+			tx.SetGold(0)
+		} else {
+			tx.SetGold(tx.Gold() - e.Amount)
+		}
+		return nil, nil
+	case Draw:
+		return DrawCards(tx, e.Amount)
+	case AddCard, AddRandom:
+		return nil, addCard(tx, e)
+	case Move:
+		return nil, a.move(tx)
+	case GainCounter, LoseCounter, Channel, Evoke, SetSlots:
+		return count(tx, e)
+	}
+
+	targets, err := a.targets(tx, e.target(), e.Radius)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*hooks.Event
+	for _, t := range targets {
+		amount := uint(e.Amount)
+		switch e.Op {
+		case Damage:
+			if lost := t.Damage(amount); lost > 0 && t.ID() == tx.Player().ID() {
+				events = append(events, &hooks.Event{Hook: hooks.DamageTaken, Actor: t.ID(), Amount: int(lost)})
+			}
+		case LoseHP:
+			t.LoseHP(amount)
+		case Block:
+			t.Block += amount
+		case Heal:
+			t.Heal(amount)
+		case GainMaxHP:
+			t.MaxHP += amount
+			t.Heal(amount)
+		default:
+			return nil, ErrUnknownOp
+		}
+	}
+	return events, nil
+}
+
+// targets of the effect, the dead monsters are excluded,
+// the radius is used by the hex target only
+func (a *Apply) targets(tx *store.Tx, target Target, radius int) ([]*actors.Actor, error) {
+	switch target {
+	case Self:
+		t, err := actor(tx, a.Source)
+		if err != nil {
+			return nil, err
+		}
+		return []*actors.Actor{t}, nil
+	case Player:
+		return []*actors.Actor{&tx.Player().Actor}, nil
+	case Chosen:
+		if a.Target == "" {
+			return nil, ErrNoTarget
+		}
+		t, err := actor(tx, a.Target)
+		if err != nil {
+			return nil, err
+		}
+		if t.Dead() {
+			return nil, nil
+		}
+		return []*actors.Actor{t}, nil
+	case AllMonsters, RandomMonster:
+		var ts []*actors.Actor
+		ms := tx.Monsters()
+		for i := range ms {
+			if !ms[i].Dead() {
+				ts = append(ts, &ms[i].Actor)
+			}
+		}
+		if target == RandomMonster && len(ts) > 0 {
+			i := tx.Rand().Intn(len(ts))
+			ts = ts[i : i+1]
+		}
+		return ts, nil
+	case Hex:
+		if a.Hex == nil {
+			return nil, ErrNoHex
+		}
+		var ts []*actors.Actor
+		for _, t := range placed(tx) {
+			if pos, _ := t.Position(); pos.Distance(*a.Hex) <= radius {
+				ts = append(ts, t)
+			}
+		}
+		return ts, nil
+	}
+	return nil, ErrUnknownTarget
+}
+
+// move the source onto the chosen hex
+func (a *Apply) move(tx *store.Tx) error {
+	if a.Hex == nil {
+		return ErrNoHex
+	}
+
+	src, err := actor(tx, a.Source)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range placed(tx) {
+		if pos, _ := t.Position(); pos == *a.Hex && t != src {
+			return ErrOccupied
+		}
+	}
+
+	src.Place(*a.Hex)
+	return nil
+}
+
+// placed returns the living actors on the hex board
+func placed(tx *store.Tx) []*actors.Actor {
+	var ts []*actors.Actor
+	if p := &tx.Player().Actor; !p.Dead() {
+		if _, ok := p.Position(); ok {
+			ts = append(ts, p)
+		}
+	}
+
+	ms := tx.Monsters()
+	for i := range ms {
+		if _, ok := ms[i].Position(); ok && !ms[i].Dead() {
+			ts = append(ts, &ms[i].Actor)
+		}
+	}
+	return ts
+}
+
+// actor by id, the player if the id is empty
+func actor(tx *store.Tx, id string) (*actors.Actor, error) {
+	if id == "" || id == tx.Player().ID() {
+		return &tx.Player().Actor, nil
+	}
+
+	m, err := tx.Monster(id)
+	if err != nil {
+		return nil, err
+	}
+	return &m.Actor, nil
+}
+
+// DrawCards from the draw pile into the hand,
+// when the draw pile is empty, the discard pile is shuffled into it
+func DrawCards(tx *store.Tx, n int) ([]*hooks.Event, error) {
+	var events []*hooks.Event
+	for i := 0; i < n && len(*tx.Pile(store.Hand)) < HandLimit; i++ {
+		if len(*tx.Pile(store.Draw)) == 0 {
+			discard := tx.Pile(store.Discard)
+			if len(*discard) == 0 {
+				break
+			}
+
+			*tx.Pile(store.Draw) = append(*tx.Pile(store.Draw), *discard...)
+			*discard = make(cards.Pile, 0)
+			tx.Shuffle(store.Draw)
+			events = append(events, &hooks.Event{Hook: hooks.Shuffle})
+		}
+
+		if _, err := tx.Draw(store.Draw, store.Hand); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// NewCard of the type without an instance id, the state gives it a fresh one
+func NewCard(typ string) (cards.Card, error) {
+	card, err := cards.New(typ)
+	if err != nil {
+		return nil, err
+	}
+	card.SetID("")
+	return card, nil
+}
+
+// addCard of the effect, a new card of the type, or a random card of the pool for add_random,
+// it's inserted into the pile at the position
+func addCard(tx *store.Tx, e Effect) error {
+	name := store.Discard
+	if e.Pile != "" {
+		var err error
+		if name, err = store.ParsePileName(e.Pile); err != nil {
+			return err
+		}
+	}
+
+	n := e.Amount
+	if n == 0 {
+		n = 1
+	}
+
+	for i := 0; i < n; i++ {
+		var card cards.Card
+		var err error
+		if e.Op == AddRandom {
+			pool := Pool{}
+			if e.Pool != nil {
+				pool = *e.Pool
+			}
+			pool.Combat = true
+			card, err = randomCard(tx.Rand(), pool)
+		} else {
+			card, err = NewCard(e.Card)
+		}
+		if err != nil {
+			return err
+		}
+		insert(tx, name, card, e.Position)
+
+		// e.g. the curse added in the battle stays in the deck
+		if p, ok := card.(Persistent); ok && p.Persistent() && name != store.Deck {
+			insert(tx, store.Deck, card.Copy(), Top)
+		}
+	}
+	return nil
+}
+
+// Persistent cards are added into the deck too, when they are added into the other piles
+type Persistent interface {
+	Persistent() bool
+}
+
+// insert the card into the pile at the position
+func insert(tx *store.Tx, name store.PileName, card cards.Card, pos Position) {
+	pile := tx.Pile(name)
+
+	i := len(*pile)
+	switch pos {
+	case Bottom:
+		i = 0
+	case Random:
+		i = tx.Rand().Intn(len(*pile) + 1)
+	}
+
+	*pile = append(*pile, nil)
+	copy((*pile)[i+1:], (*pile)[i:])
+	(*pile)[i] = card
+}
+
+// count applies the counter op, the events of the items channeled and evoked are returned
+func count(tx *store.Tx, e Effect) ([]*hooks.Event, error) {
+	c, err := tx.Counter(e.Counter, counters[e.Op])
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*hooks.Event
+	evoke := func(items []string) {
+		for _, item := range items {
+			events = append(events, &hooks.Event{Hook: hooks.Evoke, Counter: e.Counter, Item: item})
+		}
+	}
+
+	switch e.Op {
+	case GainCounter:
+		c.Value += e.Amount
+	case LoseCounter:
+		if c.Value -= e.Amount; c.Value < 0 {
+			c.Value = 0
+		}
+	case Channel:
+		n := e.Amount
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			evoke(c.Push(e.Item))
+			events = append(events, &hooks.Event{Hook: hooks.Channel, Counter: e.Counter, Item: e.Item})
+		}
+	case Evoke:
+		n := e.Amount
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			if item, ok := c.Pop(); ok {
+				evoke([]string{item})
+			}
+		}
+	case SetSlots:
+		evoke(c.Resize(e.Amount))
+	}
+	return events, nil
+}
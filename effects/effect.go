@@ -0,0 +1,203 @@
+package effects
+
+import (
+	"errors"
+
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrUnknownOp -
+	ErrUnknownOp = errors.New("unknown effect op")
+	// ErrInvalidAmount -
+	ErrInvalidAmount = errors.New("effect amount should not be negative")
+	// ErrUnknownTarget -
+	ErrUnknownTarget = errors.New("unknown effect target")
+	// ErrNoTarget -
+	ErrNoTarget = errors.New("effect requires a target")
+	// ErrNoHex -
+	ErrNoHex = errors.New("effect requires a target hex")
+	// ErrOccupied -
+	ErrOccupied = errors.New("hex is occupied")
+	// ErrUnknownPosition -
+	ErrUnknownPosition = errors.New("unknown pile position")
+	// ErrNoCounter -
+	ErrNoCounter = errors.New("effect requires a counter")
+)
+
+// Op of the effect
+type Op string
+
+const (
+	// Damage the target, block absorbs it first
+	Damage Op = "damage"
+	// Block gains block
+	Block Op = "block"
+	// Heal the target, up to its MaxHP
+	Heal Op = "heal"
+	// LoseHP ignoring the block
+	LoseHP Op = "lose_hp"
+	// GainMaxHP raises both MaxHP and HP
+	GainMaxHP Op = "gain_max_hp"
+	// GainEnergy for the player
+	GainEnergy Op = "gain_energy"
+	// Draw cards from the draw pile, the discard pile is shuffled into it when empty
+	Draw Op = "draw"
+	// GainGold for the player
+	GainGold Op = "gain_gold"
+	// LoseGold of the player, down to 0
+	LoseGold Op = "lose_gold"
+	// AddCard of the type into the pile, the discard pile by default
+	AddCard Op = "add_card"
+	// AddRandom card of the pool into the pile, the discard pile by default,
+	// the pool is a combat pool, so the healing cards are never generated
+	AddRandom Op = "add_random"
+	// Move the source onto the chosen hex
+	Move Op = "move"
+	// GainCounter adds the amount to the value counter, e.g. mantra
+	GainCounter Op = "gain_counter"
+	// LoseCounter subtracts the amount from the value counter, down to 0
+	LoseCounter Op = "lose_counter"
+	// Channel the item into the slots counter, the oldest ones are evoked if the slots are full
+	Channel Op = "channel"
+	// Evoke the oldest items of the slots counter, 1 by default
+	Evoke Op = "evoke"
+	// SetSlots of the slots counter, the oldest items are evoked if they exceed the slots
+	SetSlots Op = "set_slots"
+)
+
+var ops = map[Op]bool{
+	Damage: true, Block: true, Heal: true, LoseHP: true, GainMaxHP: true,
+	GainEnergy: true, Draw: true, GainGold: true, LoseGold: true, AddCard: true, AddRandom: true, Move: true,
+	GainCounter: true, LoseCounter: true, Channel: true, Evoke: true, SetSlots: true,
+}
+
+// counter ops of the value counters and the slots counters
+var counters = map[Op]store.CounterType{
+	GainCounter: store.Value, LoseCounter: store.Value,
+	Channel: store.Slots, Evoke: store.Slots, SetSlots: store.Slots,
+}
+
+// Target of the effect
+type Target string
+
+const (
+	// Self - the source of the effect
+	Self Target = "self"
+	// Player - the player, e.g. the target of the monster's attack
+	Player Target = "player"
+	// Chosen - the target chosen by the player
+	Chosen Target = "target"
+	// AllMonsters - every living monster
+	AllMonsters Target = "all"
+	// RandomMonster - one of the living monsters
+	RandomMonster Target = "random"
+	// Hex - every living actor placed within the radius of the chosen hex, the player included
+	Hex Target = "hex"
+)
+
+var targets = map[Target]bool{
+	Self: true, Player: true, Chosen: true, AllMonsters: true, RandomMonster: true, Hex: true,
+}
+
+// Position of the card inserted into the pile, the top of the draw pile is drawn first
+type Position string
+
+const (
+	// Top of the pile, by default
+	Top Position = "top"
+	// Random position of the pile
+	Random Position = "random"
+	// Bottom of the pile
+	Bottom Position = "bottom"
+)
+
+var positions = map[Position]bool{
+	Top: true, Random: true, Bottom: true,
+}
+
+// Effect is the data-defined vocabulary of the cards, relics and potions,
+// e.g. {"op":"damage","amount":6}, {"op":"add_card","card":"wound","pile":"draw","position":"random"}
+// or {"op":"add_random","pool":{"type":"attack"},"pile":"hand"}
+type Effect struct {
+	Op     Op     `json:"op"`
+	Amount int    `json:"amount,omitempty"`
+	Target Target `json:"target,omitempty"`
+	// Card type of add_card
+	Card string `json:"card,omitempty"`
+	// Pile name of add_card and add_random
+	Pile string `json:"pile,omitempty"`
+	// Position in the pile of add_card and add_random
+	Position Position `json:"position,omitempty"`
+	// Pool of add_random
+	Pool *Pool `json:"pool,omitempty"`
+	// Radius around the chosen hex
+	Radius int `json:"radius,omitempty"`
+	// Counter name of the counter ops, and the Item channeled
+	Counter string `json:"counter,omitempty"`
+	Item    string `json:"item,omitempty"`
+	// Scale multiplies the amount by the count of the counter, e.g. damage for each orb
+	Scale string `json:"scale,omitempty"`
+}
+
+// Validate the effect, it's useful when the effects are loaded from data
+func (e Effect) Validate() error {
+	if !ops[e.Op] {
+		return ErrUnknownOp
+	}
+
+	if e.Amount < 0 || e.Radius < 0 {
+		return ErrInvalidAmount
+	}
+
+	if e.Target != "" && !targets[e.Target] {
+		return ErrUnknownTarget
+	}
+
+	if e.Pile != "" {
+		if _, err := store.ParsePileName(e.Pile); err != nil {
+			return err
+		}
+	}
+
+	if e.Position != "" && !positions[e.Position] {
+		return ErrUnknownPosition
+	}
+
+	if _, ok := counters[e.Op]; ok && (e.Counter == "" || (e.Op == Channel && e.Item == "")) {
+		return ErrNoCounter
+	}
+	return nil
+}
+
+// target of the effect, damage targets the chosen one by default,
+// others target the source
+func (e Effect) target() Target {
+	if e.Target != "" {
+		return e.Target
+	}
+	if e.Op == Damage {
+		return Chosen
+	}
+	return Self
+}
+
+// NeedsTarget returns true if any of the effects requires a chosen target
+func NeedsTarget(effects []Effect) bool {
+	for _, e := range effects {
+		if e.target() == Chosen {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsHex returns true if any of the effects requires a chosen hex
+func NeedsHex(effects []Effect) bool {
+	for _, e := range effects {
+		if e.Op == Move || e.target() == Hex {
+			return true
+		}
+	}
+	return false
+}
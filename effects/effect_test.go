@@ -0,0 +1,262 @@
+package effects
+
+import (
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/hex"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Effect{Op: Damage, Amount: 6}.Validate())
+	assert.Nil(t, Effect{Op: AddCard, Card: "TestCard", Pile: "draw"}.Validate())
+	assert.Equal(t, ErrUnknownOp, Effect{Op: "explode"}.Validate())
+	assert.Equal(t, ErrInvalidAmount, Effect{Op: Block, Amount: -1}.Validate())
+	assert.Equal(t, ErrUnknownTarget, Effect{Op: Block, Target: "everyone"}.Validate())
+	assert.Equal(t, store.ErrInvalidPile, Effect{Op: AddCard, Pile: "graveyard"}.Validate())
+	assert.Nil(t, Effect{Op: AddCard, Card: "TestCard", Pile: "draw", Position: Random}.Validate())
+	assert.Equal(t, ErrUnknownPosition, Effect{Op: AddCard, Card: "TestCard", Position: "middle"}.Validate())
+	assert.Equal(t, ErrNoCounter, Effect{Op: GainCounter, Amount: 1}.Validate())
+	assert.Equal(t, ErrNoCounter, Effect{Op: Channel, Counter: "orbs"}.Validate())
+	assert.Nil(t, Effect{Op: Channel, Counter: "orbs", Item: "frost"}.Validate())
+
+	assert.True(t, NeedsTarget([]Effect{{Op: Block}, {Op: Damage}}))
+	assert.False(t, NeedsTarget([]Effect{{Op: Block}, {Op: Damage, Target: AllMonsters}}))
+
+	assert.Equal(t, ErrInvalidAmount, Effect{Op: Damage, Target: Hex, Radius: -1}.Validate())
+	assert.True(t, NeedsHex([]Effect{{Op: Damage, Target: Hex, Radius: 1}}))
+	assert.True(t, NeedsHex([]Effect{{Op: Move}}))
+	assert.False(t, NeedsHex([]Effect{{Op: Damage}}))
+}
+
+func newState() (*actions.Context, *store.State) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().SetID("player")
+		tx.Player().HP = 40
+		tx.Player().MaxHP = 50
+
+		var ms []actors.Monster
+		for _, id := range []string{"a", "b", "c"} {
+			m := actors.Monster{}
+			m.SetID(id)
+			m.HP = 10
+			m.MaxHP = 10
+			ms = append(ms, m)
+		}
+		tx.SetMonsters(ms)
+		return nil
+	})
+	return actions.NewContext(nil, nil, store.GetStore().AddState(state)), state
+}
+
+func TestApply(t *testing.T) {
+	ctx, state := newState()
+
+	next, err := (&Apply{Effects: []Effect{
+		{Op: Damage, Amount: 4},
+		{Op: Damage, Amount: 3, Target: AllMonsters},
+		{Op: Block, Amount: 5},
+		{Op: Heal, Amount: 20},
+		{Op: GainMaxHP, Amount: 2},
+		{Op: GainEnergy, Amount: 2},
+		{Op: GainGold, Amount: 15},
+		{Op: AddCard, Card: "TestCard", Amount: 2, Pile: "draw"},
+	}, Target: "b"}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(next))
+
+	ms := state.Monsters()
+	assert.Equal(t, []uint{7, 3, 7}, []uint{ms[0].HP, ms[1].HP, ms[2].HP})
+
+	p := state.Player()
+	assert.Equal(t, uint(5), p.Block)
+	assert.Equal(t, uint(52), p.HP)
+	assert.Equal(t, uint(52), p.MaxHP)
+	assert.Equal(t, 2, state.Energy())
+	assert.Equal(t, 15, state.Gold())
+
+	draw := state.GetPile(store.Draw)
+	assert.Equal(t, 2, len(draw))
+	assert.NotEqual(t, draw[0].ID(), draw[1].ID())
+
+	// the monster attacks the player, damage taken is triggered
+	next, err = (&Apply{Effects: []Effect{{Op: Damage, Amount: 8, Target: Player}}, Source: "a"}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, &hooks.Trigger{Event: hooks.Event{Hook: hooks.DamageTaken, Actor: "player", Amount: 3}}, next[0])
+	p = state.Player()
+	assert.Equal(t, uint(0), p.Block)
+	assert.Equal(t, uint(49), p.HP)
+
+	_, err = (&Apply{Effects: []Effect{{Op: LoseGold, Amount: 5}}}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, state.Gold())
+	_, err = (&Apply{Effects: []Effect{{Op: GainGold, Amount: 5}, {Op: LoseGold, Amount: 99}}}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, state.Gold())
+	(&Apply{Effects: []Effect{{Op: GainGold, Amount: 15}}}).Exec(ctx)
+
+	// everything is rolled back with the error
+	_, err = (&Apply{Effects: []Effect{{Op: GainGold, Amount: 1}, {Op: Damage, Amount: 1}}}).Exec(ctx)
+	assert.Equal(t, ErrNoTarget, err)
+	_, err = (&Apply{Effects: []Effect{{Op: Damage, Amount: 1}}, Target: "x"}).Exec(ctx)
+	assert.Equal(t, store.ErrActorNotExist, err)
+	_, err = (&Apply{Effects: []Effect{{Op: AddCard, Card: "x"}}}).Exec(ctx)
+	assert.Equal(t, cards.ErrUnknownType, err)
+	_, err = (&Apply{Effects: []Effect{{Op: AddRandom, Pool: &Pool{Type: "attack"}}}}).Exec(ctx)
+	assert.Equal(t, ErrNoGenerator, err)
+	assert.Equal(t, 15, state.Gold())
+}
+
+func TestHexTarget(t *testing.T) {
+	ctx, state := newState()
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().Place(hex.Coord{Q: 0, R: 0})
+		ms := tx.Monsters()
+		ms[0].Place(hex.Coord{Q: 2, R: 0})
+		ms[1].Place(hex.Coord{Q: 3, R: 0})
+		return nil
+	})
+
+	// the monsters within the radius are hit, the unplaced one is not
+	_, err := (&Apply{Effects: []Effect{{Op: Damage, Amount: 4, Target: Hex, Radius: 1}}, Hex: &hex.Coord{Q: 3, R: -1}}).Exec(ctx)
+	assert.Nil(t, err)
+	ms := state.Monsters()
+	assert.Equal(t, []uint{6, 6, 10}, []uint{ms[0].HP, ms[1].HP, ms[2].HP})
+	assert.Equal(t, uint(40), state.Player().HP)
+
+	_, err = (&Apply{Effects: []Effect{{Op: Damage, Amount: 4, Target: Hex}}}).Exec(ctx)
+	assert.Equal(t, ErrNoHex, err)
+
+	// move onto the free hex only
+	_, err = (&Apply{Effects: []Effect{{Op: Move}}, Hex: &hex.Coord{Q: 2, R: 0}}).Exec(ctx)
+	assert.Equal(t, ErrOccupied, err)
+	_, err = (&Apply{Effects: []Effect{{Op: Move}}, Hex: &hex.Coord{Q: 1, R: 0}}).Exec(ctx)
+	assert.Nil(t, err)
+	p := state.Player()
+	pos, ok := p.Position()
+	assert.True(t, ok)
+	assert.Equal(t, hex.Coord{Q: 1, R: 0}, pos)
+
+	// the snapshot keeps the positions
+	snap := state.Snapshot()
+	assert.Equal(t, &hex.Coord{Q: 1, R: 0}, snap.Player.Pos)
+	assert.Nil(t, snap.Monsters[2].Pos)
+}
+
+func TestDrawCards(t *testing.T) {
+	_, state := newState()
+	state.Update(func(tx *store.Tx) error {
+		for i := 0; i < 12; i++ {
+			c, _ := NewCard("TestCard")
+			*tx.Pile(store.Discard) = append(*tx.Pile(store.Discard), c)
+		}
+		*tx.Pile(store.Draw) = append(*tx.Pile(store.Draw), (*tx.Pile(store.Discard))[:2]...)
+		*tx.Pile(store.Discard) = (*tx.Pile(store.Discard))[2:]
+
+		// the discard pile is shuffled into the draw pile
+		evs, err := DrawCards(tx, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, []*hooks.Event{{Hook: hooks.Shuffle}}, evs)
+		assert.Equal(t, 3, len(*tx.Pile(store.Hand)))
+		assert.Equal(t, 9, len(*tx.Pile(store.Draw)))
+		assert.Equal(t, 0, len(*tx.Pile(store.Discard)))
+
+		// up to the hand limit
+		evs, err = DrawCards(tx, 20)
+		assert.Nil(t, err)
+		assert.Nil(t, evs)
+		assert.Equal(t, HandLimit, len(*tx.Pile(store.Hand)))
+		return nil
+	})
+}
+
+func TestInsert(t *testing.T) {
+	ctx, state := newState()
+	state.Update(func(tx *store.Tx) error {
+		for _, id := range []string{"a", "b", "c"} {
+			c := &cards.TestCard{}
+			c.SetID(id)
+			*tx.Pile(store.Draw) = append(*tx.Pile(store.Draw), c)
+		}
+		return nil
+	})
+
+	for _, pos := range []Position{Top, Bottom, Random, ""} {
+		_, err := (&Apply{Effects: []Effect{{Op: AddCard, Card: "TestCard", Pile: "draw", Position: pos}}}).Exec(ctx)
+		assert.Nil(t, err)
+	}
+
+	// the top is the end of the pile, where the cards are drawn from
+	draw := state.GetPile(store.Draw)
+	assert.Len(t, draw, 7)
+	ids := make(map[string]bool)
+	for _, c := range draw {
+		ids[c.ID()] = true
+	}
+	assert.Len(t, ids, 7)
+
+	orig := map[string]bool{"a": true, "b": true, "c": true}
+	added := func(i int) bool { return !orig[draw[i].ID()] }
+	assert.True(t, added(0))
+	assert.True(t, added(6))
+	n := 0
+	for i := range draw {
+		if added(i) {
+			n++
+		}
+	}
+	assert.Equal(t, 4, n)
+
+	// the original ones keep their order
+	var kept []string
+	for i, c := range draw {
+		if !added(i) {
+			kept = append(kept, c.ID())
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, kept)
+}
+
+func TestCounters(t *testing.T) {
+	ctx, state := newState()
+
+	next, err := (&Apply{Effects: []Effect{
+		{Op: GainCounter, Counter: "mantra", Amount: 5},
+		{Op: LoseCounter, Counter: "mantra", Amount: 2},
+		{Op: SetSlots, Counter: "orbs", Amount: 2},
+		{Op: Channel, Counter: "orbs", Item: "lightning", Amount: 2},
+		{Op: Channel, Counter: "orbs", Item: "frost"},
+		// damage for each orb
+		{Op: Damage, Amount: 2, Scale: "orbs", Target: AllMonsters},
+	}}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []actions.Action{
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.Channel, Counter: "orbs", Item: "lightning"}},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.Channel, Counter: "orbs", Item: "lightning"}},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.Evoke, Counter: "orbs", Item: "lightning"}},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.Channel, Counter: "orbs", Item: "frost"}},
+	}, next)
+
+	mantra, _ := state.Counter("mantra")
+	assert.Equal(t, 3, mantra.Value)
+	orbs, _ := state.Counter("orbs")
+	assert.Equal(t, []string{"lightning", "frost"}, orbs.Items)
+	assert.Equal(t, uint(6), state.Monsters()[0].HP)
+
+	next, err = (&Apply{Effects: []Effect{{Op: Evoke, Counter: "orbs", Amount: 3}, {Op: LoseCounter, Counter: "mantra", Amount: 9}}}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, next, 2)
+	orbs, _ = state.Counter("orbs")
+	assert.Len(t, orbs.Items, 0)
+	mantra, _ = state.Counter("mantra")
+	assert.Equal(t, 0, mantra.Value)
+
+	_, err = (&Apply{Effects: []Effect{{Op: Channel, Counter: "mantra", Item: "x"}}}).Exec(ctx)
+	assert.Equal(t, store.ErrCounterType, err)
+}
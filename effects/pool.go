@@ -0,0 +1,52 @@
+package effects
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+
+	"github.com/sleep2death/hexcore/cards"
+)
+
+var (
+	// ErrNoGenerator -
+	ErrNoGenerator = errors.New("random card generator is not registered")
+)
+
+// Pool of the random cards, the empty fields match any card,
+// e.g. {"type":"attack","color":"red"}, see the library package for the details
+type Pool struct {
+	Class  string `json:"class,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Rarity string `json:"rarity,omitempty"`
+	// Combat pools exclude the healing cards
+	Combat bool `json:"combat,omitempty"`
+}
+
+// Generator returns a new random card of the pool, without an id
+type Generator func(r *rand.Rand, pool Pool) (cards.Card, error)
+
+var (
+	generatorMu sync.RWMutex
+	generator   Generator
+)
+
+// RegisterGenerator of the random cards, it's registered by the library package
+func RegisterGenerator(g Generator) {
+	generatorMu.Lock()
+	generator = g
+	generatorMu.Unlock()
+}
+
+// randomCard of the pool
+func randomCard(r *rand.Rand, pool Pool) (cards.Card, error) {
+	generatorMu.RLock()
+	g := generator
+	generatorMu.RUnlock()
+
+	if g == nil {
+		return nil, ErrNoGenerator
+	}
+	return g(r, pool)
+}
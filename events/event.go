@@ -0,0 +1,219 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("event is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("event is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid event def")
+	// ErrNoEvent -
+	ErrNoEvent = errors.New("no event left for the act")
+)
+
+// Def is the data-defined event, a text adventure of pages,
+// it starts from the first page, and ends when the chosen option has no next page
+type Def struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Acts where the event can happen, any act if it's empty
+	Acts  []int  `json:"acts,omitempty"`
+	Pages []Page `json:"pages"`
+}
+
+// Page of the event
+type Page struct {
+	ID      string   `json:"id"`
+	Text    string   `json:"text"`
+	Options []Option `json:"options"`
+}
+
+// Option of the page
+type Option struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Requires Condition `json:"requires"`
+	Outcome  Outcome   `json:"outcome"`
+	// Next page id, the event ends if it's empty
+	Next string `json:"next,omitempty"`
+}
+
+// Condition of the option, it's disabled unless all of them are met
+type Condition struct {
+	// Gold at least
+	Gold int `json:"gold,omitempty"`
+	// HP more than it, so the player survives the HP loss
+	HP int `json:"hp,omitempty"`
+	// Card def id or card type in the deck, e.g. "curse"
+	Card string `json:"card,omitempty"`
+	// Upgradable card in the deck
+	Upgradable bool `json:"upgradable,omitempty"`
+}
+
+// Outcome of the option, applied in the order of the fields
+type Outcome struct {
+	// Effects applied to the player, e.g. lose_hp, heal or lose_gold
+	Effects []effects.Effect `json:"effects,omitempty"`
+	// Card def id obtained
+	Card string `json:"card,omitempty"`
+	// Upgrade the random upgradable cards of the deck
+	Upgrade int `json:"upgrade,omitempty"`
+	// Transform the random cards of the deck
+	Transform int `json:"transform,omitempty"`
+	// Fight the encounter, the reward of its kind is offered after the victory
+	Fight string `json:"fight,omitempty"`
+	// Reward kind of the fight, the normal battle reward by default
+	Reward monsters.Kind `json:"reward,omitempty"`
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the event
+func Define(def *Def) error {
+	if def.ID == "" || len(def.Pages) == 0 {
+		return ErrInvalidDef
+	}
+
+	pages := make(map[string]bool)
+	for _, p := range def.Pages {
+		if p.ID == "" || pages[p.ID] || len(p.Options) == 0 {
+			return ErrInvalidDef
+		}
+		pages[p.ID] = true
+	}
+
+	for _, p := range def.Pages {
+		for _, o := range p.Options {
+			if o.ID == "" || (o.Next != "" && !pages[o.Next]) || (o.Next != "" && o.Outcome.Fight != "") {
+				return ErrInvalidDef
+			}
+			for _, e := range o.Outcome.Effects {
+				if err := e.Validate(); err != nil {
+					return err
+				}
+				if effects.NeedsTarget([]effects.Effect{e}) || effects.NeedsHex([]effects.Effect{e}) {
+					return effects.ErrNoTarget
+				}
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+// Page by id
+func (def *Def) Page(id string) (*Page, bool) {
+	for i := range def.Pages {
+		if def.Pages[i].ID == id {
+			return &def.Pages[i], true
+		}
+	}
+	return nil, false
+}
+
+// in the act
+func (def *Def) in(act int) bool {
+	if len(def.Acts) == 0 {
+		return true
+	}
+	for _, a := range def.Acts {
+		if a == act {
+			return true
+		}
+	}
+	return false
+}
+
+// Check the condition with the state, returns the reason if it's not met
+func (c Condition) Check(state *store.State) string {
+	if c.Gold > 0 && state.Gold() < c.Gold {
+		return "not enough gold"
+	}
+
+	if p := state.Player(); c.HP > 0 && int(p.HP) <= c.HP {
+		return "not enough HP"
+	}
+
+	if c.Card == "" && !c.Upgradable {
+		return ""
+	}
+
+	found, upgradable := false, false
+	for _, card := range state.GetPile(store.Deck) {
+		if lc, ok := library.Of(card); ok {
+			found = found || lc.Type() == c.Card || string(lc.Def().Type) == c.Card
+			upgradable = upgradable || lc.Upgradable()
+		}
+	}
+
+	if c.Card != "" && !found {
+		return "no " + c.Card + " in the deck"
+	}
+	if c.Upgradable && !upgradable {
+		return "no upgradable card"
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "ev_shrine", "name": "Shrine", "acts": [1], "pages": [
+		{"id": "start", "text": "A golden shrine.", "options": [
+			{"id": "pray", "text": "Pray", "requires": {"gold": 5},
+				"outcome": {"effects": [{"op": "lose_gold", "amount": 5}], "upgrade": 1}, "next": "blessed"},
+			{"id": "desecrate", "text": "Desecrate", "requires": {"hp": 7},
+				"outcome": {"effects": [{"op": "lose_hp", "amount": 7}], "card": "ev_regret"}},
+			{"id": "wake", "text": "Wake the guardian", "outcome": {"fight": "ev_guard", "reward": "elite"}},
+			{"id": "leave", "text": "Leave"}
+		]},
+		{"id": "blessed", "text": "You feel blessed.", "options": [
+			{"id": "transform", "text": "Transform a card", "requires": {"card": "ev_strike"}, "outcome": {"transform": 1}},
+			{"id": "leave", "text": "Leave"}
+		]}
+	]},
+	{"id": "ev_forge", "acts": [3], "pages": [{"id": "start", "text": "A forge.", "options": [
+		{"id": "forge", "text": "Forge a new blade", "outcome": {"card": "ev_strike", "upgrade": 1}},
+		{"id": "reforge", "text": "Reforge a new blade", "outcome": {"card": "ev_strike", "transform": 1}}
+	]}]},
+	{"id": "ev_well", "acts": [2], "pages": [{"id": "start", "text": "A well.", "options": [{"id": "leave", "text": "Leave"}]}]},
+	{"id": "ev_cave", "acts": [2], "pages": [{"id": "start", "text": "A cave.", "options": [{"id": "leave", "text": "Leave"}]}]},
+	{"id": "ev_tree", "acts": [2], "pages": [{"id": "start", "text": "A tree.", "options": [{"id": "leave", "text": "Leave"}]}]}
+]`
+
+func init() {
+	library.Define(&library.Def{ID: "ev_strike", Name: "Strike", Type: library.Attack, Rarity: library.Basic, Color: "red",
+		Upgrade: &library.Upgrade{}})
+	library.Define(&library.Def{ID: "ev_bash", Name: "Bash", Type: library.Attack, Rarity: library.Common, Color: "red"})
+	library.Define(&library.Def{ID: "ev_zap", Name: "Zap", Type: library.Skill, Rarity: library.Common, Color: "blue"})
+	library.Define(&library.Def{ID: "ev_regret", Name: "Regret", Type: library.Curse, Rarity: library.Special})
+
+	monsters.Define(&monsters.Def{ID: "ev_golem", MinHP: 30, MaxHP: 30, Moves: []monsters.Move{
+		{ID: "slam", Effects: []effects.Effect{{Op: effects.Damage, Amount: 9}}},
+	}})
+	monsters.DefineEncounter(&monsters.Encounter{ID: "ev_guard", Kind: "event", Monsters: []string{"ev_golem"}})
+
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func newRun(t *testing.T, seed int64) (*actions.Context, *run.Run, chan []byte) {
+	r := run.New("events", seed)
+	strike, _ := library.New("ev_strike")
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 50
+		*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), strike)
+		return nil
+	})
+
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+	return ctx, r, outc
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "ev_shrine", Pages: []Page{{ID: "p", Options: []Option{{ID: "o"}}}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "ev_x"}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "ev_x", Pages: []Page{{ID: "p"}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "ev_x", Pages: []Page{{ID: "p", Options: []Option{{ID: "o", Next: "q"}}}}}))
+	assert.Equal(t, effects.ErrNoTarget, Define(&Def{ID: "ev_x", Pages: []Page{{ID: "p", Options: []Option{{ID: "o",
+		Outcome: Outcome{Effects: []effects.Effect{{Op: effects.Damage, Amount: 1}}}}}}}}))
+
+	def, err := Get("ev_shrine")
+	assert.Nil(t, err)
+	p, ok := def.Page("blessed")
+	assert.True(t, ok)
+	assert.Equal(t, "You feel blessed.", p.Text)
+}
+
+func TestRandomEvents(t *testing.T) {
+	// the same seed, the same events
+	var seqs [2][]string
+	for i := range seqs {
+		ctx, r, outc := newRun(t, 5)
+		r.Position.Act = 2
+		for j := 0; j < 3; j++ {
+			assert.Nil(t, execute(ctx, &Start{}))
+			events(outc)
+			seqs[i] = append(seqs[i], prompt.Pending(ctx).Data.(*PageData).Event)
+		}
+
+		// every event happens once
+		assert.Equal(t, ErrNoEvent, execute(ctx, &Start{}))
+	}
+	assert.Equal(t, seqs[0], seqs[1])
+	assert.ElementsMatch(t, []string{"ev_well", "ev_cave", "ev_tree"}, seqs[0])
+}
+
+func TestOutcomes(t *testing.T) {
+	ctx, r, outc := newRun(t, 1)
+
+	assert.Nil(t, execute(ctx, &Start{}))
+	assert.Equal(t, []string{"prompt"}, events(outc))
+	p := prompt.Pending(ctx)
+	assert.Equal(t, "A golden shrine.", p.Text)
+	o, _ := p.Option("pray")
+	assert.Equal(t, "not enough gold", o.Disabled)
+
+	// pray, then transform on the next page
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetGold(8)
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &Start{ID: "ev_shrine"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "pray"}))
+	assert.Equal(t, []string{"prompt", "card_upgraded", "prompt"}, events(outc))
+	assert.Equal(t, 3, r.State.Gold())
+	assert.Equal(t, 1, r.State.GetPile(store.Deck)[0].Marshal().Upgrades)
+	assert.Equal(t, "blessed", prompt.Pending(ctx).Data.(*PageData).Page)
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "transform"}))
+	assert.Equal(t, []string{"card_transformed", "event_done"}, events(outc))
+	assert.Equal(t, "ev_bash", r.State.GetPile(store.Deck)[0].Type())
+	assert.Nil(t, prompt.Pending(ctx))
+
+	// lose HP and gain the curse
+	assert.Nil(t, execute(ctx, &Start{ID: "ev_shrine"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "desecrate"}))
+	assert.Equal(t, []string{"prompt", "card_obtained", "event_done"}, events(outc))
+	assert.Equal(t, uint(43), r.State.Player().HP)
+	assert.Equal(t, "ev_regret", r.State.GetPile(store.Deck)[1].Type())
+
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 7
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &Start{ID: "ev_shrine"}))
+	o, _ = prompt.Pending(ctx).Option("desecrate")
+	assert.Equal(t, "not enough HP", o.Disabled)
+
+	// the fight starts after the event
+	events(outc)
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "wake"}))
+	assert.Equal(t, []string{"event_done", "turn_start"}, events(outc))
+	assert.Equal(t, "ev_golem-0", r.State.Monsters()[0].ID())
+}
+
+func TestOutcomeOrder(t *testing.T) {
+	ctx, r, outc := newRun(t, 1)
+	r.State.Update(func(tx *store.Tx) error {
+		*tx.Pile(store.Deck) = nil
+		return nil
+	})
+
+	// the obtained card is upgraded
+	assert.Nil(t, execute(ctx, &Start{ID: "ev_forge"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "forge"}))
+	assert.Equal(t, []string{"prompt", "card_obtained", "card_upgraded", "event_done"}, events(outc))
+	deck := r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 1)
+	assert.Equal(t, 1, deck[0].Marshal().Upgrades)
+
+	// and transformed
+	r.State.Update(func(tx *store.Tx) error {
+		*tx.Pile(store.Deck) = nil
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &Start{ID: "ev_forge"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "reforge"}))
+	assert.Equal(t, []string{"prompt", "card_obtained", "card_transformed", "event_done"}, events(outc))
+	deck = r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 1)
+	assert.Equal(t, "ev_bash", deck[0].Type())
+	assert.NotEmpty(t, deck[0].ID())
+}
@@ -0,0 +1,228 @@
+package events
+
+import (
+	"math/rand"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/dungeon"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+func init() {
+	dungeon.Register(dungeon.Event, func(ctx *actions.Context, r *run.Run, node *dungeon.Node) ([]actions.Action, error) {
+		return []actions.Action{&Start{}}, nil
+	})
+}
+
+// seen counter of the event in the run
+func seen(id string) string {
+	return "event_seen:" + id
+}
+
+// Start action picks a random event of the act, which is not seen in the run,
+// every random roll of the events is drawn from the event stream of the run,
+// so the events are deterministic with the run seed
+type Start struct {
+	// ID of the event to start instead of the random one
+	ID string
+}
+
+// Exec -
+func (a *Start) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	var def *Def
+	if a.ID != "" {
+		var err error
+		if def, err = Get(a.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		var ds []*Def
+		for _, d := range Defs() {
+			if d.in(r.Position.Act) && r.Counters[seen(d.ID)] == 0 {
+				ds = append(ds, d)
+			}
+		}
+		if len(ds) == 0 {
+			return nil, ErrNoEvent
+		}
+		def = ds[r.Rand("events").Intn(len(ds))]
+	}
+
+	r.Counters[seen(def.ID)]++
+	return []actions.Action{&page{def: def, page: &def.Pages[0]}}, nil
+}
+
+// PageData of the event prompt
+type PageData struct {
+	Event string `json:"event"`
+	Page  string `json:"page"`
+}
+
+// page action shows the page of the event
+type page struct {
+	def  *Def
+	page *Page
+}
+
+// Exec -
+func (a *page) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	state := store.GetStore().State(ctx.ID())
+
+	p := prompt.New("event", a.page.Text, a.choose)
+	p.Data = &PageData{Event: a.def.ID, Page: a.page.ID}
+	for _, o := range a.page.Options {
+		if reason := o.Requires.Check(state); reason != "" {
+			p.AddDisabled(o.ID, o.Text, reason)
+		} else {
+			p.Add(o.ID, o.Text)
+		}
+	}
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
+
+func (a *page) choose(ctx *actions.Context, option string) ([]actions.Action, error) {
+	var o *Option
+	for i := range a.page.Options {
+		if a.page.Options[i].ID == option {
+			o = &a.page.Options[i]
+		}
+	}
+	if o == nil {
+		return nil, prompt.ErrUnknownOption
+	}
+
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	next := outcome(&o.Outcome)
+
+	if o.Next != "" {
+		p, _ := a.def.Page(o.Next)
+		return append(next, &page{def: a.def, page: p}), nil
+	}
+
+	next = append(next, &actions.Emit{Event: "event_done", Data: a.def.ID})
+	if o.Outcome.Fight != "" {
+		kind := o.Outcome.Reward
+		if kind == "" {
+			kind = monsters.Normal
+		}
+		next = append(next, &dungeon.Fight{Kind: kind, Encounter: o.Outcome.Fight})
+	}
+	return next, nil
+}
+
+// outcome actions of the option, except the fight
+func outcome(oc *Outcome) []actions.Action {
+	var next []actions.Action
+	if len(oc.Effects) > 0 {
+		next = append(next, &effects.Apply{Effects: oc.Effects})
+	}
+
+	if oc.Card != "" {
+		next = append(next, &library.Obtain{ID: oc.Card})
+	}
+
+	if oc.Upgrade > 0 || oc.Transform > 0 {
+		next = append(next, &change{upgrade: oc.Upgrade, transform: oc.Transform})
+	}
+	return next
+}
+
+// change action upgrades, then transforms the random cards of the deck,
+// the deck is read in the transaction, so the cards obtained before are included
+type change struct {
+	upgrade   int
+	transform int
+}
+
+// Exec -
+func (a *change) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+	rnd := r.Rand("events")
+
+	var upgraded []cards.Card
+	var transformed []*library.Transformed
+	var into []cards.Card
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		if a.upgrade > 0 {
+			var ids []string
+			for _, c := range *tx.Pile(store.Deck) {
+				if lc, ok := library.Of(c); ok && lc.Upgradable() {
+					ids = append(ids, c.ID())
+				}
+			}
+			for _, id := range sample(rnd, ids, a.upgrade) {
+				c, _, _ := tx.Pile(store.Deck).FindCard(id)
+				up, err := library.Upgraded(c)
+				if err != nil {
+					return err
+				}
+				if _, err := library.Replace(tx, id, up); err != nil {
+					return err
+				}
+				upgraded = append(upgraded, up)
+			}
+		}
+
+		if a.transform > 0 {
+			var ids []string
+			for _, c := range *tx.Pile(store.Deck) {
+				ids = append(ids, c.ID())
+			}
+			for _, id := range sample(rnd, ids, a.transform) {
+				c, _, _ := tx.Pile(store.Deck).FindCard(id)
+				card, err := library.Transform(rnd, c)
+				if err != nil {
+					continue
+				}
+				if _, err := library.Replace(tx, id, card); err != nil {
+					return err
+				}
+				transformed = append(transformed, &library.Transformed{From: cards.Encode(c)})
+				into = append(into, card)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var next []actions.Action
+	for _, c := range upgraded {
+		next = append(next, &actions.Emit{Event: "card_upgraded", Data: cards.Encode(c)})
+	}
+	// the new cards get their ids when the transaction is committed
+	for i, ev := range transformed {
+		ev.To = cards.Encode(into[i])
+		next = append(next, &actions.Emit{Event: "card_transformed", Data: ev})
+	}
+	return next, nil
+}
+
+// sample n different ids randomly
+func sample(rnd *rand.Rand, ids []string, n int) []string {
+	rnd.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	if n < len(ids) {
+		return ids[:n]
+	}
+	return ids
+}
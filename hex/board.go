@@ -0,0 +1,182 @@
+package hex
+
+import (
+	"container/heap"
+	"errors"
+)
+
+var (
+	// ErrNoPath -
+	ErrNoPath = errors.New("no path between the hexes")
+)
+
+// Board of the hexes, every hex has a cost to move into,
+// the hexes not on the board can't be entered or seen through
+type Board struct {
+	costs  map[Coord]int
+	opaque map[Coord]bool
+}
+
+// NewBoard returns an empty board
+func NewBoard() *Board {
+	return &Board{
+		costs:  make(map[Coord]int),
+		opaque: make(map[Coord]bool),
+	}
+}
+
+// NewBoardRange returns a board of the hexes within n steps of the center, every hex costs 1
+func NewBoardRange(center Coord, n int) *Board {
+	b := NewBoard()
+	for _, c := range Range(center, n) {
+		b.Set(c, 1)
+	}
+	return b
+}
+
+// Set the cost of the hex, the hex is added if it's not on the board,
+// 0 or less means it's blocked, which can't be entered
+func (b *Board) Set(c Coord, cost int) {
+	b.costs[c] = cost
+}
+
+// Remove the hex from the board
+func (b *Board) Remove(c Coord) {
+	delete(b.costs, c)
+	delete(b.opaque, c)
+}
+
+// Contains returns true if the hex is on the board
+func (b *Board) Contains(c Coord) bool {
+	_, ok := b.costs[c]
+	return ok
+}
+
+// Cost to move into the hex, false if it can't be entered
+func (b *Board) Cost(c Coord) (int, bool) {
+	cost, ok := b.costs[c]
+	if !ok || cost <= 0 {
+		return 0, false
+	}
+	return cost, true
+}
+
+// SetOpaque marks the hex blocking the sight or not
+func (b *Board) SetOpaque(c Coord, opaque bool) {
+	if opaque {
+		b.opaque[c] = true
+	} else {
+		delete(b.opaque, c)
+	}
+}
+
+// Opaque returns true if the hex blocks the sight
+func (b *Board) Opaque(c Coord) bool {
+	return b.opaque[c] || !b.Contains(c)
+}
+
+// Visible returns true if the hex can be seen from the origin,
+// the opaque hex itself can be seen, but not the hexes behind it
+func (b *Board) Visible(origin, c Coord) bool {
+	if !b.Contains(c) {
+		return false
+	}
+
+	line := Line(origin, c)
+	for i := 1; i < len(line)-1; i++ {
+		if b.Opaque(line[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FOV returns the hexes on the board within the radius which can be seen from the origin
+func (b *Board) FOV(origin Coord, radius int) []Coord {
+	var cs []Coord
+	for _, c := range Range(origin, radius) {
+		if b.Visible(origin, c) {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// Path finds the cheapest path with A*, the start hex is excluded from the path,
+// returns ErrNoPath if the goal can't be reached
+func (b *Board) Path(from, to Coord) ([]Coord, int, error) {
+	if from == to {
+		return nil, 0, nil
+	}
+	if _, ok := b.Cost(to); !ok {
+		return nil, 0, ErrNoPath
+	}
+
+	// the heuristic must not overestimate, so use the lowest cost of the board
+	least := 0
+	for _, cost := range b.costs {
+		if cost > 0 && (least == 0 || cost < least) {
+			least = cost
+		}
+	}
+
+	came := map[Coord]Coord{}
+	costs := map[Coord]int{from: 0}
+	open := &frontier{{coord: from}}
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(item).coord
+		if cur == to {
+			break
+		}
+
+		for _, n := range cur.Neighbors() {
+			cost, ok := b.Cost(n)
+			if !ok {
+				continue
+			}
+
+			total := costs[cur] + cost
+			if old, ok := costs[n]; ok && old <= total {
+				continue
+			}
+
+			costs[n] = total
+			came[n] = cur
+			heap.Push(open, item{coord: n, priority: total + n.Distance(to)*least})
+		}
+	}
+
+	total, ok := costs[to]
+	if !ok {
+		return nil, 0, ErrNoPath
+	}
+
+	var path []Coord
+	for c := to; c != from; c = came[c] {
+		path = append(path, c)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, total, nil
+}
+
+type item struct {
+	coord    Coord
+	priority int
+}
+
+// frontier is a priority queue of the hexes, see container/heap
+type frontier []item
+
+func (f frontier) Len() int            { return len(f) }
+func (f frontier) Less(i, j int) bool  { return f[i].priority < f[j].priority }
+func (f frontier) Swap(i, j int)       { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(x interface{}) { *f = append(*f, x.(item)) }
+func (f *frontier) Pop() interface{} {
+	old := *f
+	it := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,96 @@
+package hex
+
+import "math"
+
+// Cube coordinate of the hex, X + Y + Z is always 0
+type Cube struct {
+	X, Y, Z int
+}
+
+// Cube converts the axial coordinate to cube
+func (c Coord) Cube() Cube {
+	return Cube{c.Q, -c.Q - c.R, c.R}
+}
+
+// Coord converts the cube coordinate to axial
+func (c Cube) Coord() Coord {
+	return Coord{c.X, c.Z}
+}
+
+// round the fractional cube coordinate to the nearest hex
+func round(x, y, z float64) Cube {
+	rx, ry, rz := math.Round(x), math.Round(y), math.Round(z)
+	dx, dy, dz := math.Abs(rx-x), math.Abs(ry-y), math.Abs(rz-z)
+
+	if dx > dy && dx > dz {
+		rx = -ry - rz
+	} else if dy > dz {
+		ry = -rx - rz
+	} else {
+		rz = -rx - ry
+	}
+	return Cube{int(rx), int(ry), int(rz)}
+}
+
+// Line from a to b, both ends included
+func Line(a, b Coord) []Coord {
+	n := a.Distance(b)
+	if n == 0 {
+		return []Coord{a}
+	}
+
+	// nudge the ends, so the line never lands exactly on the edges
+	const eps = 1e-6
+	ac, bc := a.Cube(), b.Cube()
+	ax, ay, az := float64(ac.X)+eps, float64(ac.Y)+eps, float64(ac.Z)-2*eps
+	bx, by, bz := float64(bc.X)+eps, float64(bc.Y)+eps, float64(bc.Z)-2*eps
+
+	line := make([]Coord, 0, n+1)
+	for i := 0; i <= n; i++ {
+		t := float64(i) / float64(n)
+		line = append(line, round(ax+(bx-ax)*t, ay+(by-ay)*t, az+(bz-az)*t).Coord())
+	}
+	return line
+}
+
+// Range of the hexes within n steps of the center, the center included
+func Range(center Coord, n int) []Coord {
+	var cs []Coord
+	for q := -n; q <= n; q++ {
+		for r := max(-n, -q-n); r <= min(n, -q+n); r++ {
+			cs = append(cs, center.Add(Coord{q, r}))
+		}
+	}
+	return cs
+}
+
+// Ring of the hexes exactly radius steps away from the center
+func Ring(center Coord, radius int) []Coord {
+	if radius <= 0 {
+		return []Coord{center}
+	}
+
+	cs := make([]Coord, 0, 6*radius)
+	c := center.Add(Coord{directions[4].Q * radius, directions[4].R * radius})
+	for dir := 0; dir < 6; dir++ {
+		for i := 0; i < radius; i++ {
+			cs = append(cs, c)
+			c = c.Neighbor(dir)
+		}
+	}
+	return cs
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,65 @@
+package hex
+
+import "strconv"
+
+// Coord is the axial coordinate of the hex, see https://www.redblobgames.com/grids/hexagons/
+type Coord struct {
+	Q int `json:"q"`
+	R int `json:"r"`
+}
+
+// directions of the neighbours, starting from the east, counter-clockwise
+var directions = [6]Coord{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// Add the other coordinate
+func (c Coord) Add(o Coord) Coord {
+	return Coord{c.Q + o.Q, c.R + o.R}
+}
+
+// Sub the other coordinate
+func (c Coord) Sub(o Coord) Coord {
+	return Coord{c.Q - o.Q, c.R - o.R}
+}
+
+// Neighbor in the direction, 0 is the east, counter-clockwise
+func (c Coord) Neighbor(dir int) Coord {
+	return c.Add(directions[((dir%6)+6)%6])
+}
+
+// Neighbors of the hex
+func (c Coord) Neighbors() []Coord {
+	ns := make([]Coord, 6)
+	for i, d := range directions {
+		ns[i] = c.Add(d)
+	}
+	return ns
+}
+
+// Distance in hexes
+func (c Coord) Distance(o Coord) int {
+	d := c.Sub(o)
+	return (abs(d.Q) + abs(d.R) + abs(d.Q+d.R)) / 2
+}
+
+// String of the coordinate, e.g. "3:-1"
+func (c Coord) String() string {
+	return strconv.Itoa(c.Q) + ":" + strconv.Itoa(c.R)
+}
+
+// FromOffset converts the "odd-r" offset coordinate to axial,
+// the odd rows are shoved right by half a hex
+func FromOffset(col, row int) Coord {
+	return Coord{col - (row-(row&1))/2, row}
+}
+
+// Offset converts the coordinate to the "odd-r" offset coordinate
+func (c Coord) Offset() (col, row int) {
+	return c.Q + (c.R-(c.R&1))/2, c.R
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoord(t *testing.T) {
+	c := Coord{1, -2}
+	assert.Equal(t, "1:-2", c.String())
+	assert.Equal(t, Coord{2, -2}, c.Neighbor(0))
+	assert.Equal(t, c.Neighbor(5), c.Neighbor(-1))
+	assert.Equal(t, c.Neighbor(1), c.Neighbor(7))
+
+	for _, n := range c.Neighbors() {
+		assert.Equal(t, 1, c.Distance(n))
+	}
+	assert.Equal(t, 0, c.Distance(c))
+	assert.Equal(t, 3, Coord{0, 0}.Distance(Coord{3, -3}))
+	assert.Equal(t, 5, Coord{-2, 0}.Distance(Coord{1, 2}))
+}
+
+func TestOffset(t *testing.T) {
+	for row := -3; row <= 3; row++ {
+		for col := -3; col <= 3; col++ {
+			c, r := FromOffset(col, row).Offset()
+			assert.Equal(t, col, c)
+			assert.Equal(t, row, r)
+		}
+	}
+
+	// the odd row is shoved right
+	assert.Equal(t, 1, FromOffset(0, 0).Distance(FromOffset(0, 1)))
+	assert.Equal(t, 1, FromOffset(1, 0).Distance(FromOffset(0, 1)))
+	assert.Equal(t, 2, FromOffset(0, 0).Distance(FromOffset(1, 1)))
+}
+
+func TestCube(t *testing.T) {
+	c := Coord{2, -3}
+	cube := c.Cube()
+	assert.Equal(t, 0, cube.X+cube.Y+cube.Z)
+	assert.Equal(t, c, cube.Coord())
+}
+
+func TestLine(t *testing.T) {
+	assert.Equal(t, []Coord{{1, 1}}, Line(Coord{1, 1}, Coord{1, 1}))
+
+	a, b := Coord{0, 0}, Coord{3, -1}
+	line := Line(a, b)
+	assert.Len(t, line, a.Distance(b)+1)
+	assert.Equal(t, a, line[0])
+	assert.Equal(t, b, line[len(line)-1])
+	for i := 1; i < len(line); i++ {
+		assert.Equal(t, 1, line[i-1].Distance(line[i]))
+	}
+
+	// straight line along the axis
+	assert.Equal(t, []Coord{{0, 0}, {0, 1}, {0, 2}}, Line(Coord{0, 0}, Coord{0, 2}))
+}
+
+func TestRangeAndRing(t *testing.T) {
+	center := Coord{1, 2}
+	assert.Equal(t, []Coord{center}, Range(center, 0))
+	assert.Len(t, Range(center, 1), 7)
+	assert.Len(t, Range(center, 2), 19)
+	for _, c := range Range(center, 2) {
+		assert.True(t, center.Distance(c) <= 2)
+	}
+
+	assert.Equal(t, []Coord{center}, Ring(center, 0))
+	for n := 1; n <= 3; n++ {
+		ring := Ring(center, n)
+		assert.Len(t, ring, 6*n)
+		seen := map[Coord]bool{}
+		for _, c := range ring {
+			assert.Equal(t, n, center.Distance(c))
+			seen[c] = true
+		}
+		assert.Len(t, seen, 6*n)
+	}
+}
+
+func TestFOV(t *testing.T) {
+	b := NewBoardRange(Coord{0, 0}, 3)
+	assert.Len(t, b.FOV(Coord{0, 0}, 3), 37)
+	assert.Len(t, b.FOV(Coord{0, 0}, 1), 7)
+
+	// the wall can be seen, but not the hexes behind it
+	b.SetOpaque(Coord{1, 0}, true)
+	assert.True(t, b.Visible(Coord{0, 0}, Coord{1, 0}))
+	assert.False(t, b.Visible(Coord{0, 0}, Coord{2, 0}))
+	assert.False(t, b.Visible(Coord{0, 0}, Coord{3, 0}))
+	assert.True(t, b.Visible(Coord{0, 0}, Coord{-3, 0}))
+	fov := b.FOV(Coord{0, 0}, 3)
+	assert.Contains(t, fov, Coord{1, 0})
+	assert.NotContains(t, fov, Coord{2, 0})
+	assert.Contains(t, fov, Coord{-3, 0})
+
+	// off the board
+	assert.False(t, b.Visible(Coord{0, 0}, Coord{4, 0}))
+	b.SetOpaque(Coord{1, 0}, false)
+	assert.True(t, b.Visible(Coord{0, 0}, Coord{3, 0}))
+}
+
+func TestPath(t *testing.T) {
+	b := NewBoardRange(Coord{0, 0}, 3)
+
+	path, cost, err := b.Path(Coord{0, 0}, Coord{3, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, []Coord{{1, 0}, {2, 0}, {3, 0}}, path)
+	assert.Equal(t, 3, cost)
+
+	path, cost, err = b.Path(Coord{0, 0}, Coord{0, 0})
+	assert.Nil(t, err)
+	assert.Len(t, path, 0)
+	assert.Equal(t, 0, cost)
+
+	// go around the expensive hex
+	b.Set(Coord{1, 0}, 5)
+	path, cost, err = b.Path(Coord{0, 0}, Coord{2, 0})
+	assert.Nil(t, err)
+	assert.Len(t, path, 3)
+	assert.Equal(t, 3, cost)
+	assert.NotContains(t, path, Coord{1, 0})
+
+	// walled off
+	for _, c := range Ring(Coord{3, -3}, 1) {
+		b.Set(c, 0)
+	}
+	_, _, err = b.Path(Coord{0, 0}, Coord{3, -3})
+	assert.Equal(t, ErrNoPath, err)
+	_, _, err = b.Path(Coord{0, 0}, Coord{9, 9})
+	assert.Equal(t, ErrNoPath, err)
+
+	b.Remove(Coord{2, 0})
+	assert.False(t, b.Contains(Coord{2, 0}))
+	_, ok := b.Cost(Coord{2, 0})
+	assert.False(t, ok)
+}
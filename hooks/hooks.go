@@ -0,0 +1,116 @@
+package hooks
+
+import (
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+)
+
+// Hook is the point of the game, where the listeners are triggered
+type Hook string
+
+const (
+	// BattleStart - the first turn of the battle is started, before the turn start
+	BattleStart Hook = "battle_start"
+	// BattleEnd - all the monsters are dead
+	BattleEnd Hook = "battle_end"
+	// TurnStart - after the cards of the turn are drawn
+	TurnStart Hook = "turn_start"
+	// TurnEnd - before the cards in hand are discarded
+	TurnEnd Hook = "turn_end"
+	// CardPlayed - after the card is paid, before its effects
+	CardPlayed Hook = "card_played"
+	// CardExhausted - the card is moved into the exhaust pile
+	CardExhausted Hook = "card_exhausted"
+	// DamageTaken - the player loses HP by the damage
+	DamageTaken Hook = "damage_taken"
+	// Shuffle - the discard pile is shuffled into the draw pile
+	Shuffle Hook = "shuffle"
+	// Rest - the player rests at the rest site
+	Rest Hook = "rest"
+	// ShopEntry - the player enters the shop
+	ShopEntry Hook = "shop_entry"
+	// Channel - the item is put into the slots counter, e.g. the orb is channeled
+	Channel Hook = "channel"
+	// Evoke - the item is removed from the slots counter, e.g. the orb is evoked
+	Evoke Hook = "evoke"
+	// RelicObtained - the relic is added to the player
+	RelicObtained Hook = "relic_obtained"
+)
+
+// Event of the hook
+type Event struct {
+	Hook Hook
+	// Card played or exhausted
+	Card cards.Card
+	// Actor id, e.g. who takes the damage
+	Actor string
+	// Amount of the event, e.g. the HP lost
+	Amount int
+	// Counter name and Item of the slots counter events, e.g. the orb evoked
+	Counter string
+	Item    string
+	// Relic obtained
+	Relic string
+}
+
+// Listener returns the actions triggered by the event,
+// they will be executed in the chain after the event
+type Listener func(ctx *actions.Context, ev *Event) []actions.Action
+
+type entry struct {
+	id int
+	l  Listener
+}
+
+var (
+	mu        sync.RWMutex
+	seq       int
+	listeners []entry
+)
+
+// Listen to all the hooks, the listeners are called in the order they are added,
+// call the returned function to remove the listener
+func Listen(l Listener) (remove func()) {
+	mu.Lock()
+	seq++
+	id := seq
+	listeners = append(listeners, entry{id: id, l: l})
+	mu.Unlock()
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, e := range listeners {
+			if e.id == id {
+				listeners = append(listeners[:i:i], listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Fire the event, returns all the actions triggered by it
+func Fire(ctx *actions.Context, ev *Event) []actions.Action {
+	mu.RLock()
+	ls := listeners
+	mu.RUnlock()
+
+	var next []actions.Action
+	for _, e := range ls {
+		next = append(next, e.l(ctx, ev)...)
+	}
+	return next
+}
+
+// Trigger action fires the event when it's executed,
+// so the listeners see the state after the previous actions
+type Trigger struct {
+	Event
+}
+
+// Exec -
+func (a *Trigger) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	return Fire(ctx, &a.Event), nil
+}
@@ -0,0 +1,37 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+	var fired []string
+	listener := func(name string) Listener {
+		return func(ctx *actions.Context, ev *Event) []actions.Action {
+			fired = append(fired, name+":"+string(ev.Hook))
+			if ev.Hook != Shuffle {
+				return nil
+			}
+			return []actions.Action{&actions.OutputString{Message: name}}
+		}
+	}
+
+	removeA := Listen(listener("a"))
+	removeB := Listen(listener("b"))
+	defer removeB()
+
+	next, err := (&Trigger{Event{Hook: Shuffle}}).Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a:shuffle", "b:shuffle"}, fired)
+	assert.Equal(t, 2, len(next))
+	assert.Equal(t, "a", next[0].(*actions.OutputString).Message)
+
+	removeA()
+	removeA()
+	fired = nil
+	assert.Nil(t, Fire(nil, &Event{Hook: Rest}))
+	assert.Equal(t, []string{"b:rest"}, fired)
+}
@@ -0,0 +1,152 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+)
+
+var (
+	// ErrNotUpgradable -
+	ErrNotUpgradable = errors.New("card can't be upgraded")
+)
+
+// Type of the card
+type Type string
+
+const (
+	// Attack card
+	Attack Type = "attack"
+	// Skill card
+	Skill Type = "skill"
+	// Power card
+	Power Type = "power"
+	// Status card
+	Status Type = "status"
+	// Curse card
+	Curse Type = "curse"
+)
+
+// Rarity of the card
+type Rarity string
+
+const (
+	// Basic cards are only in the starter decks
+	Basic Rarity = "basic"
+	// Common card
+	Common Rarity = "common"
+	// Uncommon card
+	Uncommon Rarity = "uncommon"
+	// Rare card
+	Rare Rarity = "rare"
+	// Special cards are only generated by the effects
+	Special Rarity = "special"
+)
+
+// Def is the data-defined card, every def is registered as a card type by its id
+type Def struct {
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Type    Type             `json:"type"`
+	Rarity  Rarity           `json:"rarity,omitempty"`
+	Color   string           `json:"color,omitempty"`
+	Cost    int              `json:"cost"`
+	Effects []effects.Effect `json:"effects,omitempty"`
+	// Class of the character, empty for the colorless cards
+	Class string `json:"class,omitempty"`
+	// Exhaust the card when it's played
+	Exhaust bool `json:"exhaust,omitempty"`
+	// Unplayable cards stay in hand, e.g. Wound
+	Unplayable bool `json:"unplayable,omitempty"`
+	// Ethereal cards are exhausted if they are in hand at the end of the turn, e.g. Dazed
+	Ethereal bool `json:"ethereal,omitempty"`
+	// EndTurn effects are applied if the card is in hand at the end of the turn, e.g. Burn
+	EndTurn []effects.Effect `json:"end_turn,omitempty"`
+	// Range of the chosen hex from the player, 0 means unlimited
+	Range int `json:"range,omitempty"`
+	// Upgrade of the card, nil if it can't be upgraded
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+}
+
+// Upgrade overrides the def when the card is upgraded
+type Upgrade struct {
+	Cost    *int             `json:"cost,omitempty"`
+	Effects []effects.Effect `json:"effects,omitempty"`
+}
+
+// Card instance of the def
+type Card struct {
+	cards.Base
+	def *Def
+}
+
+func newCard(def *Def) *Card {
+	c := &Card{def: def}
+	c.SetName(def.Name)
+	return c
+}
+
+// Def of the card
+func (c *Card) Def() *Def {
+	return c.def
+}
+
+// Type of the card is the id of its def
+func (c *Card) Type() string {
+	return c.def.ID
+}
+
+// String -
+func (c *Card) String() string {
+	if c.Upgrades() > 0 {
+		return fmt.Sprintf("<%s+ %s>", c.def.ID, c.ID())
+	}
+	return fmt.Sprintf("<%s %s>", c.def.ID, c.ID())
+}
+
+// Copy the card without the id, the copy is given a fresh one by the state
+func (c *Card) Copy() cards.Card {
+	return &Card{Base: c.CopyBase(""), def: c.def}
+}
+
+// Upgradable if the def has an upgrade, and the card is not upgraded yet
+func (c *Card) Upgradable() bool {
+	return c.def.Upgrade != nil && c.Upgrades() == 0
+}
+
+// Upgrade the card, only once
+func (c *Card) Upgrade() error {
+	if !c.Upgradable() {
+		return ErrNotUpgradable
+	}
+	return c.Base.Upgrade()
+}
+
+// Cost of the card, upgrade included
+func (c *Card) Cost() int {
+	if c.Upgrades() > 0 && c.def.Upgrade.Cost != nil {
+		return *c.def.Upgrade.Cost
+	}
+	return c.def.Cost
+}
+
+// Effects of the card, upgrade included
+func (c *Card) Effects() []effects.Effect {
+	if c.Upgrades() > 0 && c.def.Upgrade.Effects != nil {
+		return c.def.Upgrade.Effects
+	}
+	return c.def.Effects
+}
+
+// Persistent if it's a curse, so it stays in the deck when it's added in the battle
+func (c *Card) Persistent() bool {
+	return c.def.Type == Curse
+}
+
+// Of returns the library card, false if the card is not defined by a def
+func Of(card cards.Card) (*Card, bool) {
+	c, ok := card.(*Card)
+	return c, ok
+}
@@ -0,0 +1,150 @@
+package library
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// Obtain action adds a new card of the def into the deck (store.Deck) of the player
+type Obtain struct {
+	ID string
+}
+
+// Exec -
+func (a *Obtain) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	card, err := New(a.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), card)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&actions.Emit{Event: "card_obtained", Data: cards.Encode(card)}}, nil
+}
+
+// Upgraded returns an upgraded copy of the card, the card itself is not changed
+func Upgraded(c cards.Card) (cards.Card, error) {
+	upgraded, err := cards.Clone(c)
+	if err != nil {
+		return nil, err
+	}
+
+	lc, ok := Of(upgraded)
+	if !ok {
+		return nil, ErrNotUpgradable
+	}
+	if err := lc.Upgrade(); err != nil {
+		return nil, err
+	}
+	return upgraded, nil
+}
+
+// Replace the card of the deck of the transaction by its instance id, and returns the replaced card
+func Replace(tx *store.Tx, id string, card cards.Card) (cards.Card, error) {
+	deck := tx.Pile(store.Deck)
+	c, i, err := deck.FindCard(id)
+	if err != nil {
+		return nil, err
+	}
+	(*deck)[i] = card
+	return c, nil
+}
+
+// UpgradeCard action upgrades the card of the deck by its instance id,
+// the card is replaced by an upgraded copy, so it can be rolled back
+type UpgradeCard struct {
+	ID string
+}
+
+// Exec -
+func (a *UpgradeCard) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var upgraded cards.Card
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		c, _, err := tx.Pile(store.Deck).FindCard(a.ID)
+		if err != nil {
+			return err
+		}
+
+		if upgraded, err = Upgraded(c); err != nil {
+			return err
+		}
+		_, err = Replace(tx, a.ID, upgraded)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&actions.Emit{Event: "card_upgraded", Data: cards.Encode(upgraded)}}, nil
+}
+
+// Remove the card from the deck of the transaction by its instance id,
+// so the removal can be paid for in the same transaction
+func Remove(tx *store.Tx, id string) error {
+	deck := tx.Pile(store.Deck)
+	_, i, err := deck.FindCard(id)
+	if err != nil {
+		return err
+	}
+	*deck = append((*deck)[:i], (*deck)[i+1:]...)
+	return nil
+}
+
+// RemoveCard action removes the card from the deck by its instance id
+type RemoveCard struct {
+	ID string
+}
+
+// Exec -
+func (a *RemoveCard) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		return Remove(tx, a.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&actions.Emit{Event: "card_removed", Data: a.ID}}, nil
+}
+
+// TransformCard action replaces the card of the deck with a new card of the def
+type TransformCard struct {
+	ID   string
+	Into string
+}
+
+// Transformed event sent to the output
+type Transformed struct {
+	From cards.Data `json:"from"`
+	To   cards.Data `json:"to"`
+}
+
+// Exec -
+func (a *TransformCard) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	card, err := New(a.Into)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := &Transformed{}
+	err = store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		c, err := Replace(tx, a.ID, card)
+		if err != nil {
+			return err
+		}
+
+		ev.From = cards.Encode(c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ev.To = cards.Encode(card)
+	return []actions.Action{&actions.Emit{Event: "card_transformed", Data: ev}}, nil
+}
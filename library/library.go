@@ -0,0 +1,119 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("card is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("card is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid card def")
+)
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the card, and register it as a card type,
+// so its instances can be decoded from snapshots
+func Define(def *Def) error {
+	if def.ID == "" || def.Cost < 0 || def.Range < 0 {
+		return ErrInvalidDef
+	}
+
+	for _, e := range def.Effects {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// no one chooses the target at the end of the turn
+	if effects.NeedsTarget(def.EndTurn) || effects.NeedsHex(def.EndTurn) {
+		return effects.ErrNoTarget
+	}
+	for _, e := range def.EndTurn {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if def.Upgrade != nil {
+		for _, e := range def.Upgrade.Effects {
+			if err := e.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+
+	defs[def.ID] = def
+	cards.RegisterType(def.ID, func() cards.Card { return newCard(def) })
+	return nil
+}
+
+// Load the defs from the json array, e.g. a data file of the card pack
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+// New card instance of the def, without an id,
+// it's given the next id of the state when it's added into a pile
+func New(id string) (*Card, error) {
+	def, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return newCard(def), nil
+}
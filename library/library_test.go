@@ -0,0 +1,226 @@
+package library
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "lib_bash", "name": "Bash", "type": "attack", "rarity": "basic", "color": "red", "cost": 2,
+		"effects": [{"op": "damage", "amount": 8}],
+		"upgrade": {"effects": [{"op": "damage", "amount": 10}]}},
+	{"id": "lib_wound", "name": "Wound", "type": "status", "cost": 0}
+]`
+
+func init() {
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+
+	for _, def := range []*Def{
+		{ID: "lib_cleave", Type: Attack, Rarity: Common, Color: "red", Class: "lib_ironclad"},
+		{ID: "lib_flex", Type: Skill, Rarity: Common, Color: "red", Class: "lib_ironclad"},
+		{ID: "lib_reaper", Type: Attack, Rarity: Rare, Color: "red", Class: "lib_ironclad",
+			Upgrade: &Upgrade{Effects: []effects.Effect{{Op: effects.Heal, Amount: 5}}}},
+		{ID: "lib_neutralize", Type: Attack, Rarity: Common, Color: "green", Class: "lib_silent"},
+		{ID: "lib_swift", Type: Skill, Rarity: Uncommon, Color: "colorless"},
+		{ID: "lib_regret", Type: Curse, Rarity: Special},
+		{ID: "lib_pain", Type: Curse, Rarity: Special},
+	} {
+		if err := Define(def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	assert.Equal(t, ErrDefined, Load(strings.NewReader(data)))
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "lib_bash"}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "lib_x", Cost: -1}))
+	assert.Equal(t, effects.ErrUnknownOp, Define(&Def{ID: "lib_x", Effects: []effects.Effect{{Op: "explode"}}}))
+	assert.Equal(t, effects.ErrInvalidAmount, Define(&Def{ID: "lib_x",
+		Upgrade: &Upgrade{Effects: []effects.Effect{{Op: effects.Block, Amount: -1}}}}))
+	assert.Equal(t, effects.ErrNoTarget, Define(&Def{ID: "lib_x", EndTurn: []effects.Effect{{Op: effects.Damage, Amount: 2}}}))
+	assert.Equal(t, effects.ErrInvalidAmount, Define(&Def{ID: "lib_x", EndTurn: []effects.Effect{{Op: effects.LoseHP, Amount: -2}}}))
+
+	_, err := Get("lib_x")
+	assert.Equal(t, ErrNotDefined, err)
+
+	def, err := Get("lib_bash")
+	assert.Nil(t, err)
+	assert.Equal(t, Attack, def.Type)
+	assert.Equal(t, "red", def.Color)
+
+	var ids []string
+	for _, def := range Defs() {
+		ids = append(ids, def.ID)
+	}
+	assert.Contains(t, strings.Join(ids, ","), "lib_bash,lib_cleave,lib_flex")
+}
+
+func TestCard(t *testing.T) {
+	c, err := New("lib_bash")
+	assert.Nil(t, err)
+	assert.Empty(t, c.ID())
+	assert.Equal(t, "Bash", c.Name())
+	assert.Equal(t, 2, c.Cost())
+	assert.Equal(t, 8, c.Effects()[0].Amount)
+
+	// every instance is given the next id of the state
+	other, _ := New("lib_bash")
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c, other)
+		return nil
+	})
+	assert.Equal(t, "1", c.ID())
+	assert.Equal(t, "2", other.ID())
+
+	assert.True(t, c.Upgradable())
+	assert.Nil(t, c.Upgrade())
+	assert.Equal(t, ErrNotUpgradable, c.Upgrade())
+	assert.Equal(t, 10, c.Effects()[0].Amount)
+	assert.Equal(t, 2, c.Cost())
+	assert.Equal(t, "<lib_bash+ "+c.ID()+">", c.String())
+
+	// round trip as a registered card type
+	d, err := cards.Decode(cards.Encode(c))
+	assert.Nil(t, err)
+	lc, ok := Of(d)
+	assert.True(t, ok)
+	assert.Equal(t, c.ID(), lc.ID())
+	assert.Equal(t, 1, lc.Upgrades())
+	assert.Equal(t, 10, lc.Effects()[0].Amount)
+
+	copied := c.Copy()
+	assert.Empty(t, copied.ID())
+	assert.Equal(t, "lib_bash", copied.Type())
+
+	w, _ := New("lib_wound")
+	assert.False(t, w.Upgradable())
+	assert.False(t, w.Persistent())
+	_, ok = Of(&cards.TestCard{})
+	assert.False(t, ok)
+}
+
+func TestDeckActions(t *testing.T) {
+	state := &store.State{}
+	ctx := actions.NewContext(nil, make(chan []byte, 16), store.GetStore().AddState(state))
+
+	_, err := (&Obtain{ID: "lib_bash"}).Exec(ctx)
+	assert.Nil(t, err)
+	_, err = (&Obtain{ID: "lib_wound"}).Exec(ctx)
+	assert.Nil(t, err)
+	_, err = (&Obtain{ID: "lib_x"}).Exec(ctx)
+	assert.Equal(t, ErrNotDefined, err)
+
+	deck := state.GetPile(store.Deck)
+	bash, wound := deck[0], deck[1]
+
+	// the card in the deck is replaced by the upgraded copy
+	_, err = (&UpgradeCard{ID: bash.ID()}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, bash.Marshal().Upgrades)
+	upgraded, _ := Of(state.GetPile(store.Deck)[0])
+	assert.Equal(t, bash.ID(), upgraded.ID())
+	assert.Equal(t, 10, upgraded.Effects()[0].Amount)
+
+	_, err = (&UpgradeCard{ID: bash.ID()}).Exec(ctx)
+	assert.Equal(t, ErrNotUpgradable, err)
+	_, err = (&UpgradeCard{ID: wound.ID()}).Exec(ctx)
+	assert.Equal(t, ErrNotUpgradable, err)
+	_, err = (&UpgradeCard{ID: "x"}).Exec(ctx)
+	assert.Equal(t, cards.ErrCardNotExist, err)
+
+	_, err = (&TransformCard{ID: wound.ID(), Into: "lib_bash"}).Exec(ctx)
+	assert.Nil(t, err)
+	deck = state.GetPile(store.Deck)
+	assert.Equal(t, "lib_bash", deck[1].Type())
+	assert.NotEqual(t, wound.ID(), deck[1].ID())
+
+	_, err = (&RemoveCard{ID: bash.ID()}).Exec(ctx)
+	assert.Nil(t, err)
+	deck = state.GetPile(store.Deck)
+	assert.Equal(t, 1, len(deck))
+	assert.Equal(t, "lib_bash", deck[0].Type())
+	_, err = (&RemoveCard{ID: bash.ID()}).Exec(ctx)
+	assert.Equal(t, cards.ErrCardNotExist, err)
+}
+
+func TestPool(t *testing.T) {
+	ids := func(ds []*Def) []string {
+		var ids []string
+		for _, def := range ds {
+			ids = append(ids, def.ID)
+		}
+		return ids
+	}
+
+	// the basic, status and curse cards are excluded by default
+	assert.Equal(t, []string{"lib_cleave", "lib_flex", "lib_reaper"}, ids(PoolDefs(Pool{Class: "lib_ironclad"})))
+	assert.Equal(t, []string{"lib_bash"}, ids(PoolDefs(Pool{Color: "red", Rarity: string(Basic)})))
+	assert.Equal(t, []string{"lib_pain", "lib_regret"}, ids(PoolDefs(Pool{Type: string(Curse), Rarity: string(Special)})))
+	assert.Equal(t, []string{"lib_cleave", "lib_neutralize"}, ids(PoolDefs(Pool{Type: string(Attack), Rarity: string(Common)})))
+
+	// no healing card in combat
+	assert.True(t, (&Def{Effects: []effects.Effect{{Op: effects.GainMaxHP, Amount: 1}}}).Heals())
+	assert.False(t, (&Def{Effects: []effects.Effect{{Op: effects.Damage, Amount: 1}}}).Heals())
+	assert.Equal(t, []string{"lib_cleave", "lib_flex"}, ids(PoolDefs(Pool{Class: "lib_ironclad", Combat: true})))
+
+	// seeded generation
+	a, _ := Generate(rand.New(rand.NewSource(7)), Pool{Color: "red"}, 5)
+	b, _ := Generate(rand.New(rand.NewSource(7)), Pool{Color: "red"}, 5)
+	assert.Len(t, a, 5)
+	for i := range a {
+		assert.Equal(t, a[i].Type(), b[i].Type())
+	}
+	_, err := Generate(rand.New(rand.NewSource(7)), Pool{Color: "blue"}, 1)
+	assert.Equal(t, ErrEmptyPool, err)
+
+	// transformed into a different card from the same pool
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		c, _ := New("lib_cleave")
+		into, err := Transform(r, c)
+		assert.Nil(t, err)
+		assert.Contains(t, []string{"lib_flex", "lib_reaper"}, into.Type())
+
+		c, _ = New("lib_regret")
+		into, _ = Transform(r, c)
+		assert.Equal(t, "lib_pain", into.Type())
+		assert.True(t, into.Persistent())
+	}
+	c, _ := New("lib_swift")
+	_, err = Transform(r, c)
+	assert.Equal(t, ErrEmptyPool, err)
+
+	// the random cards of the effects
+	state := &store.State{}
+	ctx := actions.NewContext(nil, make(chan []byte, 16), store.GetStore().AddState(state))
+	_, err = (&effects.Apply{Effects: []effects.Effect{{Op: effects.AddRandom, Amount: 3, Pile: "hand",
+		Pool: &Pool{Class: "lib_ironclad"}}}}).Exec(ctx)
+	assert.Nil(t, err)
+	for _, c := range state.GetPile(store.Hand) {
+		assert.Contains(t, []string{"lib_cleave", "lib_flex"}, c.Type())
+	}
+	_, err = (&effects.Apply{Effects: []effects.Effect{{Op: effects.AddRandom, Pool: &Pool{Color: "blue"}}}}).Exec(ctx)
+	assert.Equal(t, ErrEmptyPool, err)
+}
+
+func TestPickAndRoll(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	assert.Equal(t, "", Pick(rnd, []string{"a", "b"}, []string{"b", "a"}))
+	assert.Equal(t, "b", Pick(rnd, []string{"a", "b"}, []string{"a"}))
+
+	assert.Equal(t, Common, Roll(rnd, nil))
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, Rare, Roll(rnd, map[Rarity]int{Rare: 1}))
+	}
+}
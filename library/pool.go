@@ -0,0 +1,172 @@
+package library
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/effects"
+)
+
+var (
+	// ErrEmptyPool -
+	ErrEmptyPool = errors.New("no card in the pool")
+)
+
+// Pool of the card defs, the empty fields match any def.
+// The basic and special cards are excluded unless the rarity is given,
+// so are the status and curse cards unless the type is given.
+type Pool = effects.Pool
+
+func init() {
+	effects.RegisterGenerator(func(r *rand.Rand, pool Pool) (cards.Card, error) {
+		c, err := Random(r, pool)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+// Match returns true if the def is in the pool
+func Match(pool Pool, def *Def) bool {
+	if pool.Class != "" && def.Class != pool.Class {
+		return false
+	}
+	if pool.Color != "" && def.Color != pool.Color {
+		return false
+	}
+
+	if pool.Type != "" {
+		if string(def.Type) != pool.Type {
+			return false
+		}
+	} else if def.Type == Status || def.Type == Curse {
+		return false
+	}
+
+	if pool.Rarity != "" {
+		if string(def.Rarity) != pool.Rarity {
+			return false
+		}
+	} else if def.Rarity == Basic || def.Rarity == Special {
+		return false
+	}
+
+	return !pool.Combat || !def.Heals()
+}
+
+// PoolDefs returns the defs of the pool, sorted by id
+func PoolDefs(pool Pool) []*Def {
+	var ds []*Def
+	for _, def := range Defs() {
+		if Match(pool, def) {
+			ds = append(ds, def)
+		}
+	}
+	return ds
+}
+
+// PoolOf the card def, which is used by transform:
+// the curses are transformed into curses, and the others into the cards of the same class and color
+func PoolOf(def *Def) Pool {
+	if def.Type == Curse {
+		return Pool{Type: string(Curse), Rarity: string(def.Rarity)}
+	}
+	return Pool{Class: def.Class, Color: def.Color}
+}
+
+// Random card of the pool, without an id
+func Random(r *rand.Rand, pool Pool) (*Card, error) {
+	ds := PoolDefs(pool)
+	if len(ds) == 0 {
+		return nil, ErrEmptyPool
+	}
+	return New(ds[r.Intn(len(ds))].ID)
+}
+
+// Generate n random cards of the pool
+func Generate(r *rand.Rand, pool Pool, n int) ([]*Card, error) {
+	cs := make([]*Card, 0, n)
+	for i := 0; i < n; i++ {
+		c, err := Random(r, pool)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// Transform returns a random different card from the pool of the card
+func Transform(r *rand.Rand, card cards.Card) (*Card, error) {
+	from, err := Get(card.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var ds []*Def
+	for _, def := range PoolDefs(PoolOf(from)) {
+		if def.ID != from.ID {
+			ds = append(ds, def)
+		}
+	}
+	if len(ds) == 0 {
+		return nil, ErrEmptyPool
+	}
+	return New(ds[r.Intn(len(ds))].ID)
+}
+
+// Heals returns true if the card or its upgrade heals, e.g. it's excluded from the combat pools
+func (def *Def) Heals() bool {
+	es := def.Effects
+	if def.Upgrade != nil {
+		es = append(append([]effects.Effect{}, es...), def.Upgrade.Effects...)
+	}
+
+	for _, e := range es {
+		if e.Op == effects.Heal || e.Op == effects.GainMaxHP {
+			return true
+		}
+	}
+	return false
+}
+
+// Roll the rarity by the weights, common if there is no weight,
+// the rarities are iterated in order, so the result is deterministic
+func Roll(r *rand.Rand, weights map[Rarity]int) Rarity {
+	rarities := []Rarity{Common, Uncommon, Rare}
+	total := 0
+	for _, rarity := range rarities {
+		total += weights[rarity]
+	}
+	if total <= 0 {
+		return Common
+	}
+
+	n := r.Intn(total)
+	for _, rarity := range rarities {
+		if n -= weights[rarity]; n < 0 {
+			return rarity
+		}
+	}
+	return Common
+}
+
+// Pick one of the ids, excluding the chosen ones, empty if none is left
+func Pick(r *rand.Rand, ids []string, chosen []string) string {
+	var left []string
+	for _, id := range ids {
+		found := false
+		for _, c := range chosen {
+			found = found || c == id
+		}
+		if !found {
+			left = append(left, id)
+		}
+	}
+	if len(left) == 0 {
+		return ""
+	}
+	return left[r.Intn(len(left))]
+}
@@ -0,0 +1,241 @@
+package monsters
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/effects"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("monster is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("monster is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid monster def")
+	// ErrNoEncounter -
+	ErrNoEncounter = errors.New("no encounter matches")
+)
+
+// Move of the monster, the effects target the player by default
+type Move struct {
+	ID string `json:"id"`
+	// Weight of the move, when the next move is chosen randomly, 1 by default
+	Weight  int              `json:"weight,omitempty"`
+	Effects []effects.Effect `json:"effects"`
+}
+
+func (m *Move) weight() int {
+	if m.Weight <= 0 {
+		return 1
+	}
+	return m.Weight
+}
+
+// Def is the data-defined monster
+type Def struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	MinHP uint   `json:"min_hp"`
+	MaxHP uint   `json:"max_hp"`
+	Moves []Move `json:"moves"`
+}
+
+// Kind of the encounter
+type Kind string
+
+const (
+	// Normal battle
+	Normal Kind = "battle"
+	// Elite battle
+	Elite Kind = "elite"
+	// Boss battle
+	Boss Kind = "boss"
+)
+
+// Encounter is a group of monsters fought together
+type Encounter struct {
+	ID       string   `json:"id"`
+	Kind     Kind     `json:"kind"`
+	Act      int      `json:"act"`
+	Monsters []string `json:"monsters"`
+}
+
+var (
+	mu         sync.RWMutex
+	defs       = make(map[string]*Def)
+	encounters = make(map[string]*Encounter)
+)
+
+// Define the monster
+func Define(def *Def) error {
+	if def.ID == "" || def.MinHP == 0 || def.MaxHP < def.MinHP || len(def.Moves) == 0 {
+		return ErrInvalidDef
+	}
+
+	for _, m := range def.Moves {
+		for _, e := range m.Effects {
+			if err := e.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// DefineEncounter of the monsters, they must be defined first
+func DefineEncounter(enc *Encounter) error {
+	if enc.ID == "" || enc.Kind == "" || len(enc.Monsters) == 0 {
+		return ErrInvalidDef
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, id := range enc.Monsters {
+		if _, ok := defs[id]; !ok {
+			return ErrNotDefined
+		}
+	}
+
+	if _, ok := encounters[enc.ID]; ok {
+		return ErrDefined
+	}
+	encounters[enc.ID] = enc
+	return nil
+}
+
+// Load the monsters and encounters from the json document,
+// e.g. {"monsters": [...], "encounters": [...]}
+func Load(r io.Reader) error {
+	doc := &struct {
+		Monsters   []*Def       `json:"monsters"`
+		Encounters []*Encounter `json:"encounters"`
+	}{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return err
+	}
+
+	for _, def := range doc.Monsters {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+
+	for _, enc := range doc.Encounters {
+		if err := DefineEncounter(enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// GetEncounter by id
+func GetEncounter(id string) (*Encounter, error) {
+	mu.RLock()
+	enc, ok := encounters[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return enc, nil
+}
+
+// Encounters of the act and kind, sorted by id
+func Encounters(act int, kind Kind) []*Encounter {
+	mu.RLock()
+	var es []*Encounter
+	for _, enc := range encounters {
+		if enc.Act == act && enc.Kind == kind {
+			es = append(es, enc)
+		}
+	}
+	mu.RUnlock()
+
+	sort.Slice(es, func(i, j int) bool { return es[i].ID < es[j].ID })
+	return es
+}
+
+// Pick a random encounter of the act and kind
+func Pick(r *rand.Rand, act int, kind Kind) (*Encounter, error) {
+	es := Encounters(act, kind)
+	if len(es) == 0 {
+		return nil, ErrNoEncounter
+	}
+	return es[r.Intn(len(es))], nil
+}
+
+// Spawn the monsters of the encounter, their ids are the def ids with the indexes, e.g. "slime-0"
+func Spawn(r *rand.Rand, enc *Encounter) ([]actors.Monster, error) {
+	ms := make([]actors.Monster, 0, len(enc.Monsters))
+	for i, id := range enc.Monsters {
+		m, err := New(r, id, id+"-"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// New monster of the def, its HP is rolled between MinHP and MaxHP,
+// and the first intent is chosen
+func New(r *rand.Rand, id, instance string) (actors.Monster, error) {
+	m := actors.Monster{}
+
+	def, err := Get(id)
+	if err != nil {
+		return m, err
+	}
+
+	m.SetID(instance)
+	m.Kind = def.ID
+	m.MaxHP = def.MinHP + uint(r.Intn(int(def.MaxHP-def.MinHP)+1))
+	m.HP = m.MaxHP
+	m.Intent = def.NextMove(r)
+	return m, nil
+}
+
+// NextMove chooses the index of the next move randomly by the weights
+func (def *Def) NextMove(r *rand.Rand) int {
+	total := 0
+	for i := range def.Moves {
+		total += def.Moves[i].weight()
+	}
+
+	n := r.Intn(total)
+	for i := range def.Moves {
+		if n -= def.Moves[i].weight(); n < 0 {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package monsters
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `{
+	"monsters": [
+		{"id": "mon_slime", "name": "Slime", "min_hp": 8, "max_hp": 12, "moves": [
+			{"id": "tackle", "weight": 3, "effects": [{"op": "damage", "amount": 5}]},
+			{"id": "harden", "effects": [{"op": "block", "amount": 4}]}
+		]},
+		{"id": "mon_guardian", "name": "Guardian", "min_hp": 200, "max_hp": 200, "moves": [
+			{"id": "slam", "effects": [{"op": "damage", "amount": 30}]}
+		]}
+	],
+	"encounters": [
+		{"id": "two_slimes", "kind": "battle", "act": 1, "monsters": ["mon_slime", "mon_slime"]},
+		{"id": "one_slime", "kind": "battle", "act": 1, "monsters": ["mon_slime"]},
+		{"id": "guardian", "kind": "boss", "act": 1, "monsters": ["mon_guardian"]}
+	]
+}`
+
+func init() {
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "mon_slime", MinHP: 1, MaxHP: 1, Moves: []Move{{}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "mon_x", MinHP: 5, MaxHP: 4, Moves: []Move{{}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "mon_x", MinHP: 5, MaxHP: 5}))
+	assert.Equal(t, effects.ErrUnknownOp, Define(&Def{ID: "mon_x", MinHP: 5, MaxHP: 5,
+		Moves: []Move{{Effects: []effects.Effect{{Op: "explode"}}}}}))
+
+	assert.Equal(t, ErrNotDefined, DefineEncounter(&Encounter{ID: "x", Kind: Elite, Monsters: []string{"mon_x"}}))
+	assert.Equal(t, ErrDefined, DefineEncounter(&Encounter{ID: "guardian", Kind: Boss, Monsters: []string{"mon_guardian"}}))
+
+	assert.Equal(t, 2, len(Encounters(1, Normal)))
+	assert.Equal(t, 0, len(Encounters(2, Normal)))
+	assert.Equal(t, 0, len(Encounters(1, Elite)))
+
+	enc, err := GetEncounter("guardian")
+	assert.Nil(t, err)
+	assert.Equal(t, Boss, enc.Kind)
+	_, err = GetEncounter("x")
+	assert.Equal(t, ErrNotDefined, err)
+}
+
+func TestSpawn(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	_, err := Pick(r, 1, Elite)
+	assert.Equal(t, ErrNoEncounter, err)
+
+	enc, err := Pick(r, 1, Boss)
+	assert.Nil(t, err)
+	assert.Equal(t, "guardian", enc.ID)
+
+	enc = encounter("two_slimes")
+	ms, err := Spawn(r, enc)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ms))
+	assert.Equal(t, "mon_slime-0", ms[0].ID())
+	assert.Equal(t, "mon_slime-1", ms[1].ID())
+	for _, m := range ms {
+		assert.Equal(t, "mon_slime", m.Kind)
+		assert.True(t, m.HP >= 8 && m.HP <= 12)
+		assert.Equal(t, m.HP, m.MaxHP)
+	}
+
+	// moves are chosen by the weights
+	def, _ := Get("mon_slime")
+	counts := make([]int, 2)
+	for i := 0; i < 1000; i++ {
+		counts[def.NextMove(r)]++
+	}
+	assert.InDelta(t, 750, counts[0], 60)
+
+	_, err = New(r, "mon_x", "x")
+	assert.Equal(t, ErrNotDefined, err)
+}
+
+func encounter(id string) *Encounter {
+	for _, enc := range Encounters(1, Normal) {
+		if enc.ID == id {
+			return enc
+		}
+	}
+	return nil
+}
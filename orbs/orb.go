@@ -0,0 +1,179 @@
+package orbs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("orb is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("orb is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid orb def")
+)
+
+const (
+	// Counter of the orbs channeled, the slots counter of the player,
+	// e.g. {"op":"channel","counter":"orbs","item":"lightning"}
+	Counter = "orbs"
+	// Focus is the value counter added to the damage and block of the orbs
+	Focus = "focus"
+)
+
+// Def is the data-defined orb, the passive effects are applied at the end of every turn,
+// and the evoke effects are applied when it's evoked
+type Def struct {
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Passive []effects.Effect `json:"passive,omitempty"`
+	Evoke   []effects.Effect `json:"evoke,omitempty"`
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the orb
+func Define(def *Def) error {
+	if def.ID == "" {
+		return ErrInvalidDef
+	}
+
+	for _, es := range [][]effects.Effect{def.Passive, def.Evoke} {
+		// no one chooses the target for the orb
+		if effects.NeedsTarget(es) || effects.NeedsHex(es) {
+			return effects.ErrNoTarget
+		}
+
+		for _, e := range es {
+			if err := e.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+func init() {
+	hooks.Listen(listen)
+}
+
+// listen to the end of the turn for the passives, and to the evoke of the orbs
+func listen(ctx *actions.Context, ev *hooks.Event) []actions.Action {
+	switch {
+	case ev.Hook == hooks.TurnEnd:
+		c, _ := store.GetStore().State(ctx.ID()).Counter(Counter)
+		var next []actions.Action
+		for _, id := range c.Items {
+			next = append(next, &Trigger{Orb: id})
+		}
+		return next
+	case ev.Hook == hooks.Evoke && ev.Counter == Counter:
+		return []actions.Action{&Trigger{Orb: ev.Item, Evoke: true}}
+	}
+	return nil
+}
+
+// Triggered event sent to the output
+type Triggered struct {
+	Orb   string `json:"orb"`
+	Evoke bool   `json:"evoke,omitempty"`
+}
+
+// Trigger action applies the passive or evoke effects of the orb, modified by the focus
+type Trigger struct {
+	Orb   string
+	Evoke bool
+}
+
+// Exec -
+func (a *Trigger) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	def, err := Get(a.Orb)
+	if err != nil {
+		return nil, err
+	}
+
+	es := def.Passive
+	if a.Evoke {
+		es = def.Evoke
+	}
+
+	focus, _ := store.GetStore().State(ctx.ID()).Counter(Focus)
+	return []actions.Action{
+		&actions.Emit{Event: "orb", Data: &Triggered{Orb: a.Orb, Evoke: a.Evoke}},
+		&effects.Apply{Effects: Focused(es, focus.Value)},
+	}, nil
+}
+
+// Focused copy of the effects, the focus is added to the damage and block, down to 0
+func Focused(es []effects.Effect, focus int) []effects.Effect {
+	fs := make([]effects.Effect, len(es))
+	for i, e := range es {
+		if e.Op == effects.Damage || e.Op == effects.Block {
+			if e.Amount += focus; e.Amount < 0 {
+				e.Amount = 0
+			}
+		}
+		fs[i] = e
+	}
+	return fs
+}
@@ -0,0 +1,141 @@
+package orbs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "orb_lightning", "name": "Lightning",
+		"passive": [{"op": "damage", "amount": 3, "target": "random"}],
+		"evoke": [{"op": "damage", "amount": 8, "target": "random"}]},
+	{"id": "orb_frost", "name": "Frost",
+		"passive": [{"op": "block", "amount": 2}],
+		"evoke": [{"op": "block", "amount": 5}]}
+]`
+
+func init() {
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+
+	library.Define(&library.Def{ID: "orb_zap", Name: "Zap", Type: library.Skill, Cost: 0,
+		Effects: []effects.Effect{{Op: effects.Channel, Counter: Counter, Item: "orb_lightning"}}})
+	library.Define(&library.Def{ID: "orb_chill", Name: "Chill", Type: library.Skill, Cost: 0,
+		Effects: []effects.Effect{{Op: effects.Channel, Counter: Counter, Item: "orb_frost"}}})
+	library.Define(&library.Def{ID: "orb_defrag", Name: "Defragment", Type: library.Power, Cost: 0,
+		Effects: []effects.Effect{{Op: effects.GainCounter, Counter: Focus, Amount: 1}}})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orbs triggered, sent to the output
+func triggered(outc chan []byte) []Triggered {
+	var ts []Triggered
+	for {
+		select {
+		case data := <-outc:
+			e := &struct {
+				Event string
+				Data  Triggered
+			}{}
+			json.Unmarshal(data, e)
+			if e.Event == "orb" {
+				ts = append(ts, e.Data)
+			}
+		default:
+			return ts
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "orb_frost"}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{}))
+	assert.Equal(t, effects.ErrNoTarget, Define(&Def{ID: "orb_x", Passive: []effects.Effect{{Op: effects.Damage, Amount: 1}}}))
+	assert.Equal(t, effects.ErrUnknownOp, Define(&Def{ID: "orb_x", Evoke: []effects.Effect{{Op: "explode"}}}))
+
+	_, err := Get("orb_x")
+	assert.Equal(t, ErrNotDefined, err)
+	assert.Equal(t, 2, len(Defs()))
+
+	// focus changes the damage and block only, down to 0
+	assert.Equal(t, []effects.Effect{{Op: effects.Damage, Amount: 5}, {Op: effects.GainEnergy, Amount: 1}},
+		Focused([]effects.Effect{{Op: effects.Damage, Amount: 3}, {Op: effects.GainEnergy, Amount: 1}}, 2))
+	assert.Equal(t, []effects.Effect{{Op: effects.Block, Amount: 0}}, Focused([]effects.Effect{{Op: effects.Block, Amount: 2}}, -3))
+}
+
+func TestOrbs(t *testing.T) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().SetID("player")
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 50
+		for _, id := range []string{"orb_zap", "orb_zap", "orb_chill", "orb_defrag", "orb_zap"} {
+			c, _ := library.New(id)
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+		return nil
+	})
+	outc := make(chan []byte, 256)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(state))
+
+	m := actors.Monster{}
+	m.SetID("slime")
+	m.HP, m.MaxHP = 100, 100
+	assert.Nil(t, execute(ctx, &battle.Start{Monsters: []actors.Monster{m}}))
+
+	play := func(typ string) {
+		for _, c := range state.GetPile(store.Hand) {
+			if c.Type() == typ {
+				assert.Nil(t, execute(ctx, &battle.PlayCard{ID: c.ID()}))
+				return
+			}
+		}
+		t.Fatalf("%s is not in hand", typ)
+	}
+
+	// three slots, the fourth orb evokes the oldest one
+	assert.Nil(t, execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.SetSlots, Counter: Counter, Amount: 3}}}))
+	play("orb_zap")
+	play("orb_chill")
+	play("orb_defrag")
+	play("orb_zap")
+	assert.Nil(t, triggered(outc))
+	play("orb_zap")
+	assert.Equal(t, []Triggered{{Orb: "orb_lightning", Evoke: true}}, triggered(outc))
+	assert.Equal(t, uint(91), state.Monsters()[0].HP)
+
+	orbs, _ := state.Counter(Counter)
+	assert.Equal(t, []string{"orb_frost", "orb_lightning", "orb_lightning"}, orbs.Items)
+
+	// the passives at the end of the turn, with the focus
+	assert.Nil(t, execute(ctx, &battle.EndTurn{}))
+	assert.Equal(t, []Triggered{{Orb: "orb_frost"}, {Orb: "orb_lightning"}, {Orb: "orb_lightning"}}, triggered(outc))
+	assert.Equal(t, uint(83), state.Monsters()[0].HP)
+
+	// the counters are in the snapshot
+	snap := state.Snapshot()
+	assert.Equal(t, 1, snap.Counters[Focus].Value)
+	assert.Equal(t, orbs, snap.Counters[Counter])
+}
@@ -0,0 +1,279 @@
+package potions
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("potion is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("potion is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid potion def")
+)
+
+// Def is the data-defined potion, its effects use the same vocabulary as the cards
+type Def struct {
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Rarity  library.Rarity   `json:"rarity,omitempty"`
+	Effects []effects.Effect `json:"effects"`
+	// Combat potion can only be used in the battle
+	Combat bool `json:"combat,omitempty"`
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the potion
+func Define(def *Def) error {
+	if def.ID == "" || len(def.Effects) == 0 {
+		return ErrInvalidDef
+	}
+
+	for _, e := range def.Effects {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+// Obtain action puts the potion into the first empty slot,
+// it's rejected if the slots are full
+type Obtain struct {
+	ID string
+}
+
+// Exec -
+func (a *Obtain) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	if _, err := Get(a.ID); err != nil {
+		return nil, err
+	}
+
+	var slot int
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) (err error) {
+		slot, err = tx.AddPotion(a.ID)
+		return err
+	})
+	if err != nil {
+		return actions.Reject("obtain_potion", err), nil
+	}
+	return []actions.Action{&actions.Emit{Event: "potion_obtained", Data: &Slot{Slot: slot, Potion: a.ID}}}, nil
+}
+
+// Slot event sent to the output
+type Slot struct {
+	Slot   int    `json:"slot"`
+	Potion string `json:"potion"`
+}
+
+// UsePotion input action drinks the potion in the slot,
+// it can be used at any point of the player's turn,
+// and the non-combat potions can be used outside the battle too
+type UsePotion struct {
+	Slot int
+	// Target actor id, required if the potion has a chosen target
+	Target string
+}
+
+// Exec -
+func (a *UsePotion) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var def *Def
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		ps := tx.Potions()
+		if a.Slot < 0 || a.Slot >= len(ps) {
+			return store.ErrPotionSlot
+		}
+		if ps[a.Slot] == "" {
+			return store.ErrPotionSlotEmpty
+		}
+
+		var err error
+		if def, err = Get(ps[a.Slot]); err != nil {
+			return err
+		}
+
+		inBattle := living(tx) > 0
+		if def.Combat && !inBattle {
+			return battle.ErrNotInBattle
+		}
+
+		if effects.NeedsTarget(def.Effects) {
+			if !inBattle {
+				return battle.ErrNotInBattle
+			}
+			if a.Target == "" {
+				return effects.ErrNoTarget
+			}
+			m, err := tx.Monster(a.Target)
+			if err != nil {
+				return err
+			}
+			if m.Dead() {
+				return store.ErrActorNotExist
+			}
+		}
+
+		_, err = tx.RemovePotion(a.Slot)
+		return err
+	})
+	if err != nil {
+		return actions.Reject("use_potion", err), nil
+	}
+
+	return []actions.Action{
+		&actions.Emit{Event: "potion_used", Data: &Slot{Slot: a.Slot, Potion: def.ID}},
+		&effects.Apply{Effects: def.Effects, Target: a.Target},
+		&battle.Check{},
+	}, nil
+}
+
+// DiscardPotion input action throws away the potion in the slot
+type DiscardPotion struct {
+	Slot int
+}
+
+// Exec -
+func (a *DiscardPotion) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	var id string
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) (err error) {
+		id, err = tx.RemovePotion(a.Slot)
+		return err
+	})
+	if err != nil {
+		return actions.Reject("discard_potion", err), nil
+	}
+	return []actions.Action{&actions.Emit{Event: "potion_discarded", Data: &Slot{Slot: a.Slot, Potion: id}}}, nil
+}
+
+// living monsters of the battle
+func living(tx *store.Tx) int {
+	n := 0
+	for _, m := range tx.Monsters() {
+		if !m.Dead() {
+			n++
+		}
+	}
+	return n
+}
+
+const (
+	// DropChance is the initial chance (in percent) of the potion drop
+	DropChance = 40
+	// DropStep changes the chance after every roll,
+	// it's decreased when a potion is dropped, otherwise increased
+	DropStep = 10
+)
+
+// Drop rolls for the potion drop of the battle reward with the chance (in percent),
+// returns whether a potion is dropped, and the chance of the next roll
+func Drop(r *rand.Rand, chance int) (bool, int) {
+	if r.Intn(100) < chance {
+		return true, chance - DropStep
+	}
+	return false, chance + DropStep
+}
+
+// Rarity weights of the random potions
+var Rarity = map[library.Rarity]int{
+	library.Common:   65,
+	library.Uncommon: 25,
+	library.Rare:     10,
+}
+
+// Random potion, the rarity is chosen by the weights first,
+// then one of the potions of the rarity, empty if no potion is defined
+func Random(r *rand.Rand) string {
+	pool := make(map[library.Rarity][]string)
+	for _, def := range Defs() {
+		pool[def.Rarity] = append(pool[def.Rarity], def.ID)
+	}
+
+	// iterate the rarities in order, so the result is deterministic
+	rarities := []library.Rarity{library.Common, library.Uncommon, library.Rare}
+	total := 0
+	for _, rarity := range rarities {
+		if len(pool[rarity]) > 0 {
+			total += Rarity[rarity]
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	n := r.Intn(total)
+	for _, rarity := range rarities {
+		if len(pool[rarity]) == 0 {
+			continue
+		}
+		if n -= Rarity[rarity]; n < 0 {
+			ids := pool[rarity]
+			return ids[r.Intn(len(ids))]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package potions
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/router"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "fire_potion", "name": "Fire Potion", "rarity": "common", "combat": true,
+		"effects": [{"op": "damage", "amount": 20}]},
+	{"id": "block_potion", "name": "Block Potion", "rarity": "common", "combat": true,
+		"effects": [{"op": "block", "amount": 12}]},
+	{"id": "fruit_juice", "name": "Fruit Juice", "rarity": "rare",
+		"effects": [{"op": "gain_max_hp", "amount": 5}]}
+]`
+
+func init() {
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+	library.Define(&library.Def{ID: "pot_strike", Name: "Strike", Type: library.Attack, Cost: 1,
+		Effects: []effects.Effect{{Op: effects.Damage, Amount: 6}}})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+// events sent to the output, the rejected ones are replaced by their errors
+func rejected(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &struct {
+				Event string
+				Data  json.RawMessage
+			}{}
+			json.Unmarshal(data, e)
+			if e.Event == "rejected" {
+				r := map[string]string{}
+				json.Unmarshal(e.Data, &r)
+				evs = append(evs, r["error"])
+			} else {
+				evs = append(evs, e.Event)
+			}
+		default:
+			return evs
+		}
+	}
+}
+
+func newState() (*actions.Context, *store.State, chan []byte) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 30
+		tx.Player().MaxHP = 50
+		return nil
+	})
+
+	outc := make(chan []byte, 64)
+	return actions.NewContext(nil, outc, store.GetStore().AddState(state)), state, outc
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "fire_potion", Effects: []effects.Effect{{Op: effects.Block}}}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{ID: "empty_potion"}))
+	assert.Equal(t, effects.ErrUnknownOp, Define(&Def{ID: "bad_potion", Effects: []effects.Effect{{Op: "explode"}}}))
+
+	_, err := Get("bad_potion")
+	assert.Equal(t, ErrNotDefined, err)
+}
+
+func TestUse(t *testing.T) {
+	ctx, state, outc := newState()
+
+	for _, id := range []string{"fire_potion", "block_potion", "fruit_juice", "fire_potion"} {
+		assert.Nil(t, execute(ctx, &Obtain{ID: id}))
+	}
+	assert.Equal(t, []string{"potion_obtained", "potion_obtained", "potion_obtained", "rejected"}, events(outc))
+	assert.Equal(t, []string{"fire_potion", "block_potion", "fruit_juice"}, state.Potions())
+
+	// combat potions can't be used outside the battle
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 1}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 2}))
+	assert.Equal(t, []string{"potion_used"}, events(outc))
+	assert.Equal(t, uint(55), state.Player().MaxHP)
+	assert.Equal(t, []string{"fire_potion", "block_potion", ""}, state.Potions())
+
+	m := actors.Monster{}
+	m.SetID("slime")
+	m.HP = 20
+	state.Update(func(tx *store.Tx) error {
+		tx.SetMonsters([]actors.Monster{m})
+		return nil
+	})
+
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 1}))
+	assert.Equal(t, uint(12), state.Player().Block)
+
+	// target is required
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 0}))
+	assert.Equal(t, []string{"potion_used", effects.ErrNoTarget.Error()}, rejected(outc))
+
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 0, Target: "bat"}))
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 1, Target: "slime"}))
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 5, Target: "slime"}))
+	assert.Equal(t, []string{store.ErrActorNotExist.Error(), store.ErrPotionSlotEmpty.Error(), store.ErrPotionSlot.Error()}, rejected(outc))
+
+	// the battle is won by the potion
+	assert.Nil(t, execute(ctx, &UsePotion{Slot: 0, Target: "slime"}))
+	assert.Equal(t, []string{"potion_used", "victory"}, events(outc))
+	assert.Equal(t, []string{"", "", ""}, state.Potions())
+}
+
+func TestDiscard(t *testing.T) {
+	ctx, state, outc := newState()
+	assert.Nil(t, execute(ctx, &Obtain{ID: "fire_potion"}))
+
+	assert.Nil(t, execute(ctx, &DiscardPotion{Slot: 0}))
+	assert.Nil(t, execute(ctx, &DiscardPotion{Slot: 0}))
+	assert.Equal(t, []string{"potion_obtained", "potion_discarded", "rejected"}, events(outc))
+	assert.Equal(t, []string{"", "", ""}, state.Potions())
+}
+
+func TestDrop(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	chance := DropChance
+	dropped := 0
+	for i := 0; i < 100; i++ {
+		var ok bool
+		if ok, chance = Drop(r, chance); ok {
+			dropped++
+		}
+	}
+	// the chance is balanced around 50%
+	assert.InDelta(t, 50, dropped, 10)
+
+	ok, next := Drop(r, 100)
+	assert.True(t, ok)
+	assert.Equal(t, 90, next)
+	ok, next = Drop(r, 0)
+	assert.False(t, ok)
+	assert.Equal(t, 10, next)
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[Random(r)]++
+	}
+	assert.Equal(t, 3, len(counts))
+	assert.True(t, counts["fruit_juice"] < counts["fire_potion"])
+
+	// deterministic with the same seed
+	assert.Equal(t, Random(rand.New(rand.NewSource(7))), Random(rand.New(rand.NewSource(7))))
+}
+
+func TestRoute(t *testing.T) {
+	r := router.New()
+	Route(r)
+
+	action, err := r.Serve(&router.Request{Type: router.Normal, Path: "/potion/use/1", Payload: []byte(`{"target":"slime"}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, &UsePotion{Slot: 1, Target: "slime"}, action)
+
+	action, err = r.Serve(&router.Request{Type: router.Normal, Path: "/potion/discard/2"})
+	assert.Nil(t, err)
+	assert.Equal(t, &DiscardPotion{Slot: 2}, action)
+
+	_, err = r.Serve(&router.Request{Type: router.Normal, Path: "/potion/use/x"})
+	assert.NotNil(t, err)
+}
+
+func TestUndo(t *testing.T) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 30
+		tx.Player().MaxHP = 50
+		for i := 0; i < 5; i++ {
+			c, _ := library.New("pot_strike")
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+		return nil
+	})
+
+	in := make(chan actions.Action, 1)
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(in, outc, store.GetStore().AddState(state))
+
+	m := actors.Monster{}
+	m.SetID("slime")
+	m.HP = 50
+	assert.Nil(t, execute(ctx, &Obtain{ID: "block_potion"}))
+	assert.Nil(t, execute(ctx, &battle.Start{Monsters: []actors.Monster{m}}))
+	events(outc)
+
+	input := func(a actions.Action) {
+		in <- a
+		assert.Nil(t, execute(ctx, &actions.WaitForInput{}))
+	}
+
+	// the potion can't be undone, neither can the card played before it
+	input(&battle.PlayCard{ID: state.GetPile(store.Hand)[0].ID(), Target: "slime"})
+	assert.Nil(t, state.CanUndo())
+	input(&UsePotion{Slot: 0})
+	assert.Equal(t, uint(12), state.Player().Block)
+	input(&actions.Undo{})
+
+	assert.Equal(t, store.ErrNothingToUndo, state.CanUndo())
+	assert.Equal(t, []string{"", "", ""}, state.Potions())
+	assert.Equal(t, 4, len(state.GetPile(store.Hand)))
+	assert.Equal(t, uint(44), state.Monsters()[0].HP)
+}
@@ -0,0 +1,36 @@
+package potions
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/router"
+)
+
+// Route the potion input actions
+func Route(r *router.Router) {
+	r.Handle(router.Normal, "/potion/use/:slot<uint>", use,
+		router.WithDoc("use the potion in the slot"), router.WithPayload(battle.TargetPayload{}))
+	r.Handle(router.Normal, "/potion/discard/:slot<uint>", discard,
+		router.WithDoc("discard the potion in the slot"))
+}
+
+func use(req *router.Request, ps router.Params) (actions.Action, error) {
+	slot, err := ps.Uint("slot")
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := battle.DecodeTarget(req)
+	if err != nil {
+		return nil, err
+	}
+	return &UsePotion{Slot: int(slot), Target: target}, nil
+}
+
+func discard(req *router.Request, ps router.Params) (actions.Action, error) {
+	slot, err := ps.Uint("slot")
+	if err != nil {
+		return nil, err
+	}
+	return &DiscardPotion{Slot: int(slot)}, nil
+}
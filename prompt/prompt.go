@@ -0,0 +1,121 @@
+package prompt
+
+import (
+	"errors"
+
+	"github.com/sleep2death/hexcore/actions"
+)
+
+var (
+	// ErrNoPrompt -
+	ErrNoPrompt = errors.New("nothing to choose")
+	// ErrUnknownOption -
+	ErrUnknownOption = errors.New("unknown option")
+	// ErrDisabled -
+	ErrDisabled = errors.New("option is disabled")
+	// ErrPending -
+	ErrPending = errors.New("choose the option of the pending prompt first")
+)
+
+// Option of the prompt
+type Option struct {
+	ID   string `json:"id"`
+	Text string `json:"text,omitempty"`
+	// Disabled is the reason why the option can't be chosen, empty if it's enabled
+	Disabled string `json:"disabled,omitempty"`
+}
+
+// Handler is called with the chosen option, the prompt is closed before it,
+// so ask again in the next actions if the player has more to choose
+type Handler func(ctx *actions.Context, option string) ([]actions.Action, error)
+
+// Prompt is a choice waiting for the player, e.g. the reward screen or the campfire
+type Prompt struct {
+	// Kind of the prompt, e.g. "reward"
+	Kind    string      `json:"kind"`
+	Text    string      `json:"text,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Options []Option    `json:"options"`
+
+	handler Handler
+}
+
+// New prompt of the kind
+func New(kind, text string, handler Handler) *Prompt {
+	return &Prompt{Kind: kind, Text: text, Options: make([]Option, 0), handler: handler}
+}
+
+// Add the option
+func (p *Prompt) Add(id, text string) *Prompt {
+	p.Options = append(p.Options, Option{ID: id, Text: text})
+	return p
+}
+
+// AddDisabled adds the option which can't be chosen for the reason
+func (p *Prompt) AddDisabled(id, text, reason string) *Prompt {
+	p.Options = append(p.Options, Option{ID: id, Text: text, Disabled: reason})
+	return p
+}
+
+// Option by id
+func (p *Prompt) Option(id string) (Option, bool) {
+	for _, o := range p.Options {
+		if o.ID == id {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// key of the pending prompt in the chain context
+type key struct{}
+
+// Pending prompt of the chain, nil if there isn't one
+func Pending(ctx *actions.Context) *Prompt {
+	p, _ := ctx.Value(key{}).(*Prompt)
+	return p
+}
+
+// Close the pending prompt of the chain, if any
+func Close(ctx *actions.Context) {
+	ctx.SetValue(key{}, nil)
+}
+
+// Ask action makes the prompt pending, and sends it to the output,
+// it replaces the previous pending prompt
+type Ask struct {
+	Prompt *Prompt
+}
+
+// Exec -
+func (a *Ask) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	ctx.SetValue(key{}, a.Prompt)
+	return []actions.Action{&actions.Emit{Event: "prompt", Data: a.Prompt}}, nil
+}
+
+// Choose input action chooses the option of the pending prompt
+type Choose struct {
+	Option string
+}
+
+// Exec -
+func (a *Choose) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	p := Pending(ctx)
+	if p == nil {
+		return actions.Reject("choose", ErrNoPrompt), nil
+	}
+
+	o, ok := p.Option(a.Option)
+	if !ok {
+		return actions.Reject("choose", ErrUnknownOption), nil
+	}
+	if o.Disabled != "" {
+		return actions.Reject("choose", ErrDisabled), nil
+	}
+
+	Close(ctx)
+	if p.handler == nil {
+		return nil, nil
+	}
+	return p.handler(ctx, o.ID)
+}
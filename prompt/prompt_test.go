@@ -0,0 +1,66 @@
+package prompt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPrompt(t *testing.T) {
+	outc := make(chan []byte, 16)
+	ctx := actions.NewContext(nil, outc, 0)
+
+	var chosen []string
+	p := New("campfire", "rest or smith", func(ctx *actions.Context, option string) ([]actions.Action, error) {
+		chosen = append(chosen, option)
+		return nil, nil
+	})
+	p.Add("rest", "heal 30%").AddDisabled("smith", "upgrade a card", "no upgradable card")
+
+	// nothing to choose yet
+	assert.Nil(t, execute(ctx, &Choose{Option: "rest"}))
+	assert.Equal(t, `{"event":"rejected","data":{"action":"choose","error":"nothing to choose"}}`, string(<-outc))
+
+	assert.Nil(t, execute(ctx, &Ask{Prompt: p}))
+	assert.Equal(t, p, Pending(ctx))
+	assert.Equal(t, `{"event":"prompt","data":{"kind":"campfire","text":"rest or smith","options":[`+
+		`{"id":"rest","text":"heal 30%"},{"id":"smith","text":"upgrade a card","disabled":"no upgradable card"}]}}`, string(<-outc))
+
+	assert.Nil(t, execute(ctx, &Choose{Option: "dig"}))
+	assert.Nil(t, execute(ctx, &Choose{Option: "smith"}))
+	for _, err := range []error{ErrUnknownOption, ErrDisabled} {
+		e := &struct{ Data map[string]string }{}
+		json.Unmarshal(<-outc, e)
+		assert.Equal(t, err.Error(), e.Data["error"])
+	}
+
+	// the prompt is closed after the choice
+	assert.Nil(t, execute(ctx, &Choose{Option: "rest"}))
+	assert.Equal(t, []string{"rest"}, chosen)
+	assert.Nil(t, Pending(ctx))
+}
+
+func TestRoute(t *testing.T) {
+	r := router.New()
+	Route(r)
+
+	action, err := r.Serve(&router.Request{Type: router.Normal, Path: "/choose/card:strike"})
+	assert.Nil(t, err)
+	assert.Equal(t, &Choose{Option: "card:strike"}, action)
+}
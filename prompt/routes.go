@@ -0,0 +1,20 @@
+package prompt
+
+import (
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/router"
+)
+
+// Route the prompt input actions
+func Route(r *router.Router) {
+	r.Handle(router.Normal, "/choose/:option<id>", choose,
+		router.WithDoc("choose the option of the pending prompt"))
+}
+
+func choose(req *router.Request, ps router.Params) (actions.Action, error) {
+	option, err := ps.ID("option")
+	if err != nil {
+		return nil, err
+	}
+	return &Choose{Option: option}, nil
+}
@@ -0,0 +1,241 @@
+package relics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrDefined -
+	ErrDefined = errors.New("relic is already defined")
+	// ErrNotDefined -
+	ErrNotDefined = errors.New("relic is not defined")
+	// ErrInvalidDef -
+	ErrInvalidDef = errors.New("invalid relic def")
+)
+
+// Trigger of the relic, the effects are applied when the hook is fired
+type Trigger struct {
+	Hook hooks.Hook `json:"hook"`
+	// Card type filter of the card hooks, e.g. "attack"
+	Card library.Type `json:"card,omitempty"`
+	// Every n-th matched event activates the relic, counted by the relic counter of the trigger
+	Every   int              `json:"every,omitempty"`
+	Effects []effects.Effect `json:"effects"`
+}
+
+// match the event
+func (t *Trigger) match(ev *hooks.Event) bool {
+	if t.Hook != ev.Hook {
+		return false
+	}
+
+	if t.Card != "" {
+		c, ok := library.Of(ev.Card)
+		if !ok || c.Def().Type != t.Card {
+			return false
+		}
+	}
+	return true
+}
+
+// Def is the data-defined relic
+type Def struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Rarity   library.Rarity `json:"rarity,omitempty"`
+	Triggers []Trigger      `json:"triggers,omitempty"`
+}
+
+var (
+	mu   sync.RWMutex
+	defs = make(map[string]*Def)
+)
+
+// Define the relic
+func Define(def *Def) error {
+	if def.ID == "" {
+		return ErrInvalidDef
+	}
+
+	for _, t := range def.Triggers {
+		if t.Hook == "" || t.Every < 0 {
+			return ErrInvalidDef
+		}
+
+		// no one chooses the target for the relic
+		if effects.NeedsTarget(t.Effects) {
+			return effects.ErrNoTarget
+		}
+
+		for _, e := range t.Effects {
+			if err := e.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := defs[def.ID]; ok {
+		return ErrDefined
+	}
+	defs[def.ID] = def
+	return nil
+}
+
+// Load the defs from the json array
+func Load(r io.Reader) error {
+	var ds []*Def
+	if err := json.NewDecoder(r).Decode(&ds); err != nil {
+		return err
+	}
+
+	for _, def := range ds {
+		if err := Define(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the def by id
+func Get(id string) (*Def, error) {
+	mu.RLock()
+	def, ok := defs[id]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotDefined
+	}
+	return def, nil
+}
+
+// Defs returns all the defs sorted by id
+func Defs() []*Def {
+	mu.RLock()
+	ds := make([]*Def, 0, len(defs))
+	for _, def := range defs {
+		ds = append(ds, def)
+	}
+	mu.RUnlock()
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i].ID < ds[j].ID })
+	return ds
+}
+
+func init() {
+	hooks.Listen(listen)
+}
+
+// listen to the hooks for all the relics owned by the player,
+// the relic obtained hook is only listened by the obtained relic itself
+func listen(ctx *actions.Context, ev *hooks.Event) []actions.Action {
+	var next []actions.Action
+	for _, r := range store.GetStore().State(ctx.ID()).Relics() {
+		if ev.Hook == hooks.RelicObtained && ev.Relic != r.ID {
+			continue
+		}
+
+		def, err := Get(r.ID)
+		if err != nil {
+			continue
+		}
+
+		for i := range def.Triggers {
+			if def.Triggers[i].match(ev) {
+				next = append(next, &Activate{Relic: r.ID, Trigger: i})
+			}
+		}
+	}
+	return next
+}
+
+// Activation event sent to the output
+type Activation struct {
+	Relic string     `json:"relic"`
+	Hook  hooks.Hook `json:"hook"`
+}
+
+// Activate action counts the trigger of the relic,
+// and applies its effects when the count is reached
+type Activate struct {
+	Relic   string
+	Trigger int
+}
+
+// Exec -
+func (a *Activate) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	def, err := Get(a.Relic)
+	if err != nil {
+		return nil, err
+	}
+	t := def.Triggers[a.Trigger]
+
+	activated := false
+	err = store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		r, err := tx.Relic(a.Relic)
+		if err != nil {
+			return err
+		}
+
+		if t.Every <= 1 {
+			activated = true
+			return nil
+		}
+
+		n := r.Count(a.Trigger) + 1
+		if n >= t.Every {
+			n = 0
+			activated = true
+		}
+		r.SetCount(a.Trigger, n)
+		return nil
+	})
+
+	// the relic may be removed by the previous actions
+	if err != nil && err != store.ErrRelicNotExist {
+		return nil, err
+	}
+	if !activated {
+		return nil, nil
+	}
+
+	return []actions.Action{
+		&actions.Emit{Event: "relic", Data: &Activation{Relic: a.Relic, Hook: t.Hook}},
+		&effects.Apply{Effects: t.Effects},
+	}, nil
+}
+
+// Obtain action adds the relic to the player, and fires the relic obtained hook
+type Obtain struct {
+	ID string
+}
+
+// Exec -
+func (a *Obtain) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	if _, err := Get(a.ID); err != nil {
+		return nil, err
+	}
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		return tx.AddRelic(a.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{
+		&actions.Emit{Event: "relic_obtained", Data: a.ID},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.RelicObtained, Relic: a.ID}},
+	}, nil
+}
@@ -0,0 +1,207 @@
+package relics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const data = `[
+	{"id": "relic_nunchaku", "name": "Nunchaku", "rarity": "common", "triggers": [
+		{"hook": "card_played", "card": "attack", "every": 3, "effects": [{"op": "gain_energy", "amount": 1}]}
+	]},
+	{"id": "relic_anchor", "name": "Anchor", "rarity": "common", "triggers": [
+		{"hook": "battle_start", "effects": [{"op": "block", "amount": 10}]}
+	]},
+	{"id": "relic_twin", "name": "Twin", "triggers": [
+		{"hook": "card_played", "every": 2, "effects": [{"op": "gain_gold", "amount": 1}]},
+		{"hook": "card_played", "every": 3, "effects": [{"op": "gain_gold", "amount": 10}]},
+		{"hook": "card_played", "effects": [{"op": "gain_gold", "amount": 100}]}
+	]},
+	{"id": "relic_strawberry", "name": "Strawberry", "rarity": "common", "triggers": [
+		{"hook": "relic_obtained", "effects": [{"op": "gain_max_hp", "amount": 7}]}
+	]},
+	{"id": "relic_bomb", "name": "Bomb", "triggers": [
+		{"hook": "shuffle", "effects": [{"op": "damage", "amount": 3, "target": "all"}]},
+		{"hook": "damage_taken", "effects": [{"op": "gain_gold", "amount": 5}]}
+	]}
+]`
+
+func init() {
+	if err := Load(strings.NewReader(data)); err != nil {
+		panic(err)
+	}
+	library.Define(&library.Def{ID: "relic_strike", Name: "Strike", Type: library.Attack,
+		Effects: []effects.Effect{{Op: effects.Damage, Amount: 1}}})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relic activations sent to the output
+func activations(outc chan []byte) []string {
+	var ids []string
+	for {
+		select {
+		case data := <-outc:
+			e := &struct {
+				Event string
+				Data  Activation
+			}{}
+			json.Unmarshal(data, e)
+			if e.Event == "relic" {
+				ids = append(ids, e.Data.Relic)
+			}
+		default:
+			return ids
+		}
+	}
+}
+
+func newBattle(t *testing.T, relics ...string) (*actions.Context, *store.State, chan []byte) {
+	state := &store.State{}
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 50
+		for i := 0; i < 6; i++ {
+			c, _ := library.New("relic_strike")
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+		return nil
+	})
+
+	outc := make(chan []byte, 256)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(state))
+	for _, id := range relics {
+		assert.Nil(t, execute(ctx, &Obtain{ID: id}))
+	}
+
+	m := actors.Monster{}
+	m.SetID("slime")
+	m.HP = 100
+	m.MaxHP = 100
+	assert.Nil(t, execute(ctx, &battle.Start{Monsters: []actors.Monster{m}}))
+	return ctx, state, outc
+}
+
+func TestDefine(t *testing.T) {
+	assert.Equal(t, ErrDefined, Define(&Def{ID: "relic_anchor"}))
+	assert.Equal(t, ErrInvalidDef, Define(&Def{}))
+	assert.Equal(t, effects.ErrNoTarget, Define(&Def{ID: "relic_x", Triggers: []Trigger{
+		{Hook: "turn_start", Effects: []effects.Effect{{Op: effects.Damage, Amount: 1}}}}}))
+	assert.Equal(t, effects.ErrUnknownOp, Define(&Def{ID: "relic_x", Triggers: []Trigger{
+		{Hook: "turn_start", Effects: []effects.Effect{{Op: "explode"}}}}}))
+
+	def, err := Get("relic_nunchaku")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, def.Triggers[0].Every)
+	_, err = Get("relic_x")
+	assert.Equal(t, ErrNotDefined, err)
+}
+
+func TestObtain(t *testing.T) {
+	ctx, state, outc := newBattle(t, "relic_anchor")
+	assert.Equal(t, []string{"relic_anchor"}, activations(outc))
+	assert.Equal(t, uint(10), state.Player().Block)
+
+	assert.Equal(t, store.ErrRelicExists, execute(ctx, &Obtain{ID: "relic_anchor"}))
+	assert.Equal(t, ErrNotDefined, execute(ctx, &Obtain{ID: "relic_x"}))
+
+	// the obtain triggers are only activated by the relic itself
+	assert.Nil(t, execute(ctx, &Obtain{ID: "relic_strawberry"}))
+	assert.Equal(t, []string{"relic_strawberry"}, activations(outc))
+	assert.Equal(t, uint(57), state.Player().MaxHP)
+
+	assert.Nil(t, execute(ctx, &Obtain{ID: "relic_nunchaku"}))
+	assert.Equal(t, 0, len(activations(outc)))
+	assert.Equal(t, uint(57), state.Player().MaxHP)
+}
+
+func TestCounter(t *testing.T) {
+	ctx, state, outc := newBattle(t, "relic_nunchaku")
+
+	play := func() {
+		hand := state.GetPile(store.Hand)
+		assert.Nil(t, execute(ctx, &battle.PlayCard{ID: hand[0].ID(), Target: "slime"}))
+	}
+
+	play()
+	play()
+	assert.Equal(t, []store.Relic{{ID: "relic_nunchaku", Counters: map[int]int{0: 2}}}, state.Relics())
+	assert.Equal(t, 0, len(activations(outc)))
+
+	// the counter is saved in the snapshot
+	snap := state.Snapshot()
+	assert.Equal(t, 2, snap.Relics[0].Count(0))
+
+	play()
+	assert.Equal(t, []string{"relic_nunchaku"}, activations(outc))
+	assert.Equal(t, battle.Energy+1, state.Energy())
+	assert.Equal(t, 0, state.Relics()[0].Count(0))
+
+	// restored from the snapshot, the counter continues
+	assert.Nil(t, state.Restore(snap))
+	play()
+	assert.Equal(t, []string{"relic_nunchaku"}, activations(outc))
+}
+
+func TestTriggerCounters(t *testing.T) {
+	ctx, state, _ := newBattle(t, "relic_twin")
+
+	for i := 0; i < 3; i++ {
+		hand := state.GetPile(store.Hand)
+		assert.Nil(t, execute(ctx, &battle.PlayCard{ID: hand[0].ID(), Target: "slime"}))
+	}
+
+	// every trigger counts on its own
+	assert.Equal(t, 3*100+1+10, state.Gold())
+	assert.Equal(t, []store.Relic{{ID: "relic_twin", Counters: map[int]int{0: 1}}}, state.Relics())
+}
+
+func TestHooks(t *testing.T) {
+	ctx, state, outc := newBattle(t, "relic_bomb")
+
+	// damage taken
+	err := execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 4, Target: effects.Player}}, Source: "slime"})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, state.Gold())
+
+	// blocked damage is not taken
+	state.Update(func(tx *store.Tx) error {
+		tx.Player().Block = 10
+		return nil
+	})
+	execute(ctx, &effects.Apply{Effects: []effects.Effect{{Op: effects.Damage, Amount: 4, Target: effects.Player}}, Source: "slime"})
+	assert.Equal(t, 5, state.Gold())
+	assert.Equal(t, []string{"relic_bomb"}, activations(outc))
+
+	// shuffle on the second turn
+	assert.Nil(t, execute(ctx, &battle.EndTurn{}))
+	assert.Equal(t, uint(97), state.Monsters()[0].HP)
+	assert.Equal(t, []string{"relic_bomb"}, activations(outc))
+
+	// removed relic won't be activated
+	state.Update(func(tx *store.Tx) error {
+		return tx.RemoveRelic("relic_bomb")
+	})
+	assert.Nil(t, execute(ctx, &Activate{Relic: "relic_bomb"}))
+	assert.Equal(t, 0, len(activations(outc)))
+}
@@ -0,0 +1,343 @@
+package rest
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/rewards"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// HealPercent of the MaxHP healed by resting
+var HealPercent = 30
+
+// HealValue is the amount healed by resting, modified by the run modifiers
+const HealValue = "rest_heal"
+
+// LiftMax is the max number of the lifts
+const LiftMax = 3
+
+// relics granting the options
+const (
+	// Shovel grants the dig option, which obtains a random relic
+	Shovel = "shovel"
+	// Girya grants the lift option, which is counted by the uses of the relic,
+	// and every lift grants 1 strength at the start of the battles
+	Girya = "girya"
+	// PeacePipe grants the toke option, which removes a card from the deck
+	PeacePipe = "peace_pipe"
+)
+
+// Option of the rest site
+type Option struct {
+	ID   string
+	Text string
+	// Relic required for the option, it's hidden without the relic
+	Relic string
+	// Disabled returns the reason why the option can't be chosen now, nil if it's always enabled
+	Disabled func(s *Site) string
+	// Choose returns the actions of the option,
+	// which should end with s.Done(), or s.Back() if the player cancels it
+	Choose func(s *Site) ([]actions.Action, error)
+}
+
+var (
+	mu      sync.RWMutex
+	options []*Option
+	// relics forbidding the options, e.g. no resting with the coffee dripper
+	forbidden = make(map[string][]string)
+)
+
+// Register the option, it replaces the option with the same id,
+// the options are shown in the order of registration.
+// The returned func removes the option.
+func Register(o *Option) (remove func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	remove = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, opt := range options {
+			if opt == o {
+				options = append(options[:i:i], options[i+1:]...)
+				return
+			}
+		}
+	}
+
+	for i, opt := range options {
+		if opt.ID == o.ID {
+			options[i] = o
+			return remove
+		}
+	}
+	options = append(options, o)
+	return remove
+}
+
+// Forbid the option while the player owns the relic
+func Forbid(option, relic string) {
+	mu.Lock()
+	forbidden[option] = append(forbidden[option], relic)
+	mu.Unlock()
+}
+
+func init() {
+	Register(&Option{ID: "rest", Text: "rest and heal", Disabled: full, Choose: heal})
+	Register(&Option{ID: "smith", Text: "upgrade a card", Disabled: noUpgradable, Choose: smith})
+	Register(&Option{ID: "dig", Text: "obtain a random relic", Relic: Shovel, Choose: dig})
+	Register(&Option{ID: "lift", Text: "gain strength", Relic: Girya, Disabled: lifted, Choose: lift})
+	Register(&Option{ID: "toke", Text: "remove a card", Relic: PeacePipe, Disabled: noCard, Choose: toke})
+
+	hooks.Listen(lifts)
+}
+
+// Site is the rest site the player is in
+type Site struct {
+	Run   *run.Run
+	State *store.State
+}
+
+// Done action ends the rest site
+func (s *Site) Done() actions.Action {
+	return &actions.Emit{Event: "rest_done"}
+}
+
+// Back action shows the options of the site again
+func (s *Site) Back() actions.Action {
+	return &screen{site: s}
+}
+
+// owns the relic
+func (s *Site) owns(relic string) bool {
+	for _, r := range s.State.Relics() {
+		if r.ID == relic {
+			return true
+		}
+	}
+	return false
+}
+
+// Enter action shows the options of the rest site
+type Enter struct {
+}
+
+// Exec -
+func (a *Enter) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+	return []actions.Action{&screen{site: &Site{Run: r, State: store.GetStore().State(ctx.ID())}}}, nil
+}
+
+// screen action asks the player to choose one of the options
+type screen struct {
+	site *Site
+}
+
+// Exec -
+func (a *screen) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	s := a.site
+
+	mu.RLock()
+	opts := make([]*Option, len(options))
+	copy(opts, options)
+	mu.RUnlock()
+
+	p := prompt.New("rest", "", func(ctx *actions.Context, id string) ([]actions.Action, error) {
+		if id == "leave" {
+			return []actions.Action{s.Done()}, nil
+		}
+		for _, o := range opts {
+			if o.ID == id {
+				return o.Choose(s)
+			}
+		}
+		return nil, prompt.ErrUnknownOption
+	})
+
+	for _, o := range opts {
+		if o.Relic != "" && !s.owns(o.Relic) {
+			continue
+		}
+
+		if reason := disabled(s, o); reason != "" {
+			p.AddDisabled(o.ID, o.Text, reason)
+		} else {
+			p.Add(o.ID, o.Text)
+		}
+	}
+
+	p.Add("leave", "leave the rest site")
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
+
+// disabled reason of the option
+func disabled(s *Site, o *Option) string {
+	mu.RLock()
+	relics := forbidden[o.ID]
+	mu.RUnlock()
+
+	for _, relic := range relics {
+		if s.owns(relic) {
+			return "forbidden by " + relic
+		}
+	}
+
+	if o.Disabled != nil {
+		return o.Disabled(s)
+	}
+	return ""
+}
+
+func full(s *Site) string {
+	if p := s.State.Player(); p.HP >= p.MaxHP {
+		return "HP is full"
+	}
+	return ""
+}
+
+// Rested event sent to the output
+type Rested struct {
+	Amount int `json:"amount"`
+}
+
+func heal(s *Site) ([]actions.Action, error) {
+	var amount uint
+	err := s.State.Update(func(tx *store.Tx) error {
+		p := tx.Player()
+		heal := s.Run.Value(HealValue, int(p.MaxHP)*HealPercent/100)
+		if heal < 0 {
+			heal = 0
+		}
+		amount = p.Heal(uint(heal))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []actions.Action{
+		&actions.Emit{Event: "rested", Data: &Rested{Amount: int(amount)}},
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.Rest, Amount: int(amount)}},
+		s.Done(),
+	}, nil
+}
+
+// upgradable cards of the deck
+func upgradable(s *Site) []*library.Card {
+	var cs []*library.Card
+	for _, c := range s.State.GetPile(store.Deck) {
+		if lc, ok := library.Of(c); ok && lc.Upgradable() {
+			cs = append(cs, lc)
+		}
+	}
+	return cs
+}
+
+func noUpgradable(s *Site) string {
+	if len(upgradable(s)) == 0 {
+		return "no upgradable card"
+	}
+	return ""
+}
+
+// smith asks the player to choose the card to upgrade
+func smith(s *Site) ([]actions.Action, error) {
+	p := prompt.New("smith", "", func(ctx *actions.Context, id string) ([]actions.Action, error) {
+		if id == "cancel" {
+			return []actions.Action{s.Back()}, nil
+		}
+		return []actions.Action{&library.UpgradeCard{ID: id}, s.Done()}, nil
+	})
+
+	for _, c := range upgradable(s) {
+		p.Add(c.ID(), c.Name())
+	}
+	p.Add("cancel", "back to the campfire")
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
+
+func dig(s *Site) ([]actions.Action, error) {
+	id := rewards.RandomRelic(s.Run, s.Run.Rand("rest"))
+	if id == "" {
+		return []actions.Action{s.Done()}, nil
+	}
+	return []actions.Action{&relics.Obtain{ID: id}, s.Done()}, nil
+}
+
+func lifted(s *Site) string {
+	for _, r := range s.State.Relics() {
+		if r.ID == Girya && r.Uses >= LiftMax {
+			return "lifted " + strconv.Itoa(LiftMax) + " times"
+		}
+	}
+	return ""
+}
+
+// lifts grant the strength at the start of the battle
+func lifts(ctx *actions.Context, ev *hooks.Event) []actions.Action {
+	if ev.Hook != hooks.BattleStart {
+		return nil
+	}
+
+	for _, r := range store.GetStore().State(ctx.ID()).Relics() {
+		if r.ID == Girya && r.Uses > 0 {
+			return []actions.Action{&effects.Apply{Effects: []effects.Effect{
+				{Op: effects.GainCounter, Counter: battle.Strength, Amount: r.Uses},
+			}}}
+		}
+	}
+	return nil
+}
+
+func lift(s *Site) ([]actions.Action, error) {
+	var n int
+	err := s.State.Update(func(tx *store.Tx) error {
+		r, err := tx.Relic(Girya)
+		if err != nil {
+			return err
+		}
+		r.Uses++
+		n = r.Uses
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&actions.Emit{Event: "lifted", Data: n}, s.Done()}, nil
+}
+
+func noCard(s *Site) string {
+	if len(s.State.GetPile(store.Deck)) == 0 {
+		return "no card to remove"
+	}
+	return ""
+}
+
+// toke asks the player to choose the card to remove
+func toke(s *Site) ([]actions.Action, error) {
+	p := prompt.New("toke", "", func(ctx *actions.Context, id string) ([]actions.Action, error) {
+		if id == "cancel" {
+			return []actions.Action{s.Back()}, nil
+		}
+
+		return []actions.Action{&library.RemoveCard{ID: id}, s.Done()}, nil
+	})
+
+	for _, c := range s.State.GetPile(store.Deck) {
+		p.Add(c.ID(), c.Type())
+	}
+	p.Add("cancel", "back to the campfire")
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
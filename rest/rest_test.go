@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/battle"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	library.Define(&library.Def{ID: "rest_strike", Name: "Strike", Type: library.Attack, Upgrade: &library.Upgrade{}})
+	library.Define(&library.Def{ID: "rest_wound", Name: "Wound", Type: library.Status})
+	library.Define(&library.Def{ID: "rest_bash", Name: "Bash", Type: library.Attack, Cost: 1,
+		Effects: []effects.Effect{{Op: effects.Damage, Amount: 8}}})
+	relics.Define(&relics.Def{ID: "rest_idol", Rarity: library.Common})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func newRun(t *testing.T) (*actions.Context, *run.Run, chan []byte) {
+	r := run.New("rest", 1)
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 80
+		for _, id := range []string{"rest_strike", "rest_wound"} {
+			c, _ := library.New(id)
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+		return nil
+	})
+
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+	return ctx, r, outc
+}
+
+// ids of the options, with the disabled reasons
+func shown(ctx *actions.Context) map[string]string {
+	opts := make(map[string]string)
+	for _, o := range prompt.Pending(ctx).Options {
+		opts[o.ID] = o.Disabled
+	}
+	return opts
+}
+
+func TestRestAndSmith(t *testing.T) {
+	ctx, r, outc := newRun(t)
+
+	rested := 0
+	defer hooks.Listen(func(c *actions.Context, ev *hooks.Event) []actions.Action {
+		if c == ctx && ev.Hook == hooks.Rest {
+			rested += ev.Amount
+		}
+		return nil
+	})()
+
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, map[string]string{"rest": "", "smith": "", "leave": ""}, shown(ctx))
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "rest"}))
+	assert.Equal(t, []string{"prompt", "rested", "rest_done"}, events(outc))
+	assert.Equal(t, uint(74), r.State.Player().HP)
+	assert.Equal(t, 24, rested)
+	assert.Nil(t, prompt.Pending(ctx))
+
+	// cancel the smith, then upgrade the card
+	strike := r.State.GetPile(store.Deck)[0].ID()
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "smith"}))
+	assert.Equal(t, "smith", prompt.Pending(ctx).Kind)
+	assert.Len(t, prompt.Pending(ctx).Options, 2)
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "cancel"}))
+	assert.Equal(t, "rest", prompt.Pending(ctx).Kind)
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "smith"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: strike}))
+	assert.Equal(t, []string{"prompt", "prompt", "prompt", "prompt", "card_upgraded", "rest_done"}, events(outc))
+	assert.Equal(t, 1, r.State.GetPile(store.Deck)[0].Marshal().Upgrades)
+
+	// nothing to upgrade, and the relic forbids resting
+	r.State.Update(func(tx *store.Tx) error {
+		return tx.AddRelic("rest_dripper")
+	})
+	Forbid("rest", "rest_dripper")
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, map[string]string{"rest": "forbidden by rest_dripper", "smith": "no upgradable card", "leave": ""}, shown(ctx))
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "leave"}))
+	assert.Equal(t, []string{"prompt", "rest_done"}, events(outc))
+}
+
+func TestRelicOptions(t *testing.T) {
+	ctx, r, outc := newRun(t)
+	r.State.Update(func(tx *store.Tx) error {
+		tx.Player().HP = 80
+		for _, id := range []string{Shovel, Girya, PeacePipe} {
+			tx.AddRelic(id)
+		}
+		return nil
+	})
+
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, map[string]string{"rest": "HP is full", "smith": "", "dig": "", "lift": "", "toke": "", "leave": ""}, shown(ctx))
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "dig"}))
+	assert.Equal(t, []string{"prompt", "relic_obtained", "rest_done"}, events(outc))
+	assert.Len(t, r.State.Relics(), 4)
+
+	for i := 0; i < LiftMax; i++ {
+		assert.Nil(t, execute(ctx, &Enter{}))
+		assert.Nil(t, execute(ctx, &prompt.Choose{Option: "lift"}))
+	}
+	assert.Equal(t, LiftMax, r.State.Relics()[1].Uses)
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, "lifted 3 times", shown(ctx)["lift"])
+
+	wound := r.State.GetPile(store.Deck)[1].ID()
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "toke"}))
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: wound}))
+	deck := r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 1)
+	assert.Equal(t, "rest_strike", deck[0].Type())
+
+	// a new option can be registered
+	defer Register(&Option{ID: "recall", Text: "recall", Choose: func(s *Site) ([]actions.Action, error) {
+		return []actions.Action{s.Done()}, nil
+	}})()
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, "recall", prompt.Pending(ctx).Options[5].ID)
+}
+
+func TestLifts(t *testing.T) {
+	ctx, r, _ := newRun(t)
+	r.State.Update(func(tx *store.Tx) error {
+		c, _ := library.New("rest_bash")
+		*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		tx.AddRelic(Girya)
+		g, _ := tx.Relic(Girya)
+		g.Uses = 2
+		// the trigger counters don't change the lifts
+		g.SetCount(0, 5)
+		return nil
+	})
+
+	m := actors.Monster{}
+	m.SetID("slime")
+	m.HP = 20
+	m.MaxHP = 20
+	assert.Nil(t, execute(ctx, &battle.Start{Monsters: []actors.Monster{m}}))
+	strength, _ := r.State.Counter(battle.Strength)
+	assert.Equal(t, 2, strength.Count())
+
+	var bash string
+	for _, c := range r.State.GetPile(store.Hand) {
+		if c.Type() == "rest_bash" {
+			bash = c.ID()
+		}
+	}
+	assert.Nil(t, execute(ctx, &battle.PlayCard{ID: bash, Target: "slime"}))
+	assert.Equal(t, uint(10), r.State.Monsters()[0].HP)
+}
@@ -0,0 +1,296 @@
+package rewards
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/classes"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/potions"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// Choices of the card reward
+const Choices = 3
+
+// Gold range of the reward by the room kind, both ends included
+var Gold = map[monsters.Kind][2]int{
+	monsters.Normal: {10, 20},
+	monsters.Elite:  {25, 35},
+	monsters.Boss:   {95, 105},
+}
+
+// Chances (in percent) of the rare and uncommon cards, the rest are common
+type Chances struct {
+	Rare     int
+	Uncommon int
+}
+
+// Rarities of the card reward by the room kind
+var Rarities = map[monsters.Kind]Chances{
+	monsters.Normal: {Rare: 3, Uncommon: 37},
+	monsters.Elite:  {Rare: 10, Uncommon: 40},
+	monsters.Boss:   {Rare: 100},
+}
+
+const (
+	// PityStep raises the rare chance after every common card offered,
+	// it's reset when a rare card is offered
+	PityStep = 1
+	// PityMax is the max of the raised rare chance
+	PityMax = 40
+)
+
+// Relic is dropped by the room kind
+var Relic = map[monsters.Kind]bool{
+	monsters.Elite: true,
+	monsters.Boss:  true,
+}
+
+// values of the run modified by the run modifiers, e.g. the ascension
+const (
+	// GoldValue is the gold of the reward
+	GoldValue = "reward_gold"
+	// PotionValue is the potion drop chance (in percent) of the reward
+	PotionValue = "potion_chance"
+)
+
+// counters of the run
+const (
+	pityCounter   = "card_pity"
+	potionCounter = "potion_chance"
+)
+
+// Reward of the battle, the items are removed from it when they are taken
+type Reward struct {
+	Gold   int      `json:"gold,omitempty"`
+	Cards  []string `json:"cards,omitempty"`
+	Potion string   `json:"potion,omitempty"`
+	Relic  string   `json:"relic,omitempty"`
+}
+
+// Empty if everything is taken
+func (rw *Reward) Empty() bool {
+	return rw.Gold == 0 && len(rw.Cards) == 0 && rw.Potion == "" && rw.Relic == ""
+}
+
+// Generate the reward of the room kind from the reward stream of the run,
+// the pity counter and the potion chance of the run are updated too.
+// The gold and the potion chance are modified by the run modifiers.
+func Generate(r *run.Run, kind monsters.Kind) *Reward {
+	rnd := r.Rand("rewards")
+	rw := &Reward{}
+
+	if g, ok := Gold[kind]; ok {
+		rw.Gold = r.Value(GoldValue, g[0]+rnd.Intn(g[1]-g[0]+1))
+	}
+
+	rw.Cards = cardChoices(r, rnd, Rarities[kind])
+
+	// the counter keeps the unmodified chance, only the step is added to it,
+	// and the chance stays in 0..100
+	base := percent(potions.DropChance + r.Counters[potionCounter])
+	chance := r.Value(PotionValue, base)
+	dropped, next := potions.Drop(rnd, chance)
+	r.Counters[potionCounter] = percent(base+next-chance) - potions.DropChance
+	if dropped {
+		rw.Potion = potions.Random(rnd)
+	}
+
+	if Relic[kind] {
+		rw.Relic = RandomRelic(r, rnd)
+	}
+	return rw
+}
+
+// percent clamps the chance into 0..100
+func percent(chance int) int {
+	if chance < 0 {
+		return 0
+	}
+	if chance > 100 {
+		return 100
+	}
+	return chance
+}
+
+// cardChoices rolls the rarity of every choice, and picks a different card of it from the class pool
+func cardChoices(r *run.Run, rnd *rand.Rand, chances Chances) []string {
+	pool := make(map[library.Rarity][]string)
+	for _, rarity := range []library.Rarity{library.Common, library.Uncommon, library.Rare} {
+		p := classes.Pool(r.State)
+		p.Rarity = string(rarity)
+		for _, def := range library.PoolDefs(p) {
+			pool[rarity] = append(pool[rarity], def.ID)
+		}
+	}
+
+	var choices []string
+	for i := 0; i < Choices; i++ {
+		rare := chances.Rare + r.Counters[pityCounter]
+		if rare > PityMax && chances.Rare < PityMax {
+			rare = PityMax
+		}
+
+		rarity := library.Common
+		switch n := rnd.Intn(100); {
+		case n < rare:
+			rarity = library.Rare
+			r.Counters[pityCounter] = 0
+		case n < rare+chances.Uncommon:
+			rarity = library.Uncommon
+		default:
+			r.Counters[pityCounter] += PityStep
+		}
+
+		// fall back to the other rarities, if every card of the rarity is chosen
+		id := library.Pick(rnd, pool[rarity], choices)
+		for _, fallback := range []library.Rarity{library.Common, library.Uncommon, library.Rare} {
+			if id != "" {
+				break
+			}
+			id = library.Pick(rnd, pool[fallback], choices)
+		}
+		if id == "" {
+			break
+		}
+		choices = append(choices, id)
+	}
+	return choices
+}
+
+// RandomRelic which is not owned by the player, the starter and special relics are excluded,
+// empty if there is none left
+func RandomRelic(r *run.Run, rnd *rand.Rand) string {
+	var owned []string
+	for _, relic := range r.State.Relics() {
+		owned = append(owned, relic.ID)
+	}
+
+	var ids []string
+	for _, def := range relics.Defs() {
+		if def.Rarity == library.Basic || def.Rarity == library.Special {
+			continue
+		}
+		ids = append(ids, def.ID)
+	}
+	return library.Pick(rnd, ids, owned)
+}
+
+// PendingReward is the kind of the run pending, when the reward is offered but not taken,
+// the reward is kept as the data
+const PendingReward = "reward"
+
+func init() {
+	run.OnResume(PendingReward, func(ctx *actions.Context, r *run.Run, p *run.Pending) ([]actions.Action, error) {
+		rw := &Reward{}
+		if err := json.Unmarshal(p.Data, rw); err != nil {
+			return nil, err
+		}
+		return []actions.Action{&screen{reward: rw}}, nil
+	})
+}
+
+// Offer action generates the reward of the room kind, and shows the reward screen,
+// the run is saved with the reward pending before the screen is shown,
+// so the resumed run offers the same reward again
+type Offer struct {
+	Kind monsters.Kind
+}
+
+// Exec -
+func (a *Offer) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	rw := Generate(r, a.Kind)
+	p, err := run.NewPending(PendingReward, rw)
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&run.SafePoint{Pending: p}, &screen{reward: rw}}, nil
+}
+
+// screen action asks the player to take the items of the reward one by one,
+// it's built when executed, so the options reflect the latest state
+type screen struct {
+	reward *Reward
+}
+
+// Exec -
+func (a *screen) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	rw := a.reward
+	if rw.Empty() {
+		return []actions.Action{&actions.Emit{Event: "reward_done"}}, nil
+	}
+
+	p := prompt.New("reward", "", a.choose)
+	p.Data = rw
+
+	if rw.Gold > 0 {
+		p.Add("gold", strconv.Itoa(rw.Gold)+" gold")
+	}
+
+	if rw.Potion != "" {
+		if store.GetStore().State(ctx.ID()).PotionSlotsFull() {
+			p.AddDisabled("potion", rw.Potion, "potion slots are full")
+		} else {
+			p.Add("potion", rw.Potion)
+		}
+	}
+
+	if rw.Relic != "" {
+		p.Add("relic", rw.Relic)
+	}
+
+	for _, id := range rw.Cards {
+		p.Add("card:"+id, id)
+	}
+
+	p.Add("skip", "leave the rest of the reward")
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
+
+func (a *screen) choose(ctx *actions.Context, option string) ([]actions.Action, error) {
+	rw := a.reward
+
+	var next []actions.Action
+	switch {
+	case option == "skip":
+		return []actions.Action{&actions.Emit{Event: "reward_done"}}, nil
+	case option == "gold":
+		gold := rw.Gold
+		rw.Gold = 0
+		err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+			tx.SetGold(tx.Gold() + gold)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		next = append(next, &actions.Emit{Event: "gold_obtained", Data: gold})
+	case option == "potion":
+		next = append(next, &potions.Obtain{ID: rw.Potion})
+		rw.Potion = ""
+	case option == "relic":
+		next = append(next, &relics.Obtain{ID: rw.Relic})
+		rw.Relic = ""
+	case strings.HasPrefix(option, "card:"):
+		// only one of the cards can be taken
+		next = append(next, &library.Obtain{ID: strings.TrimPrefix(option, "card:")})
+		rw.Cards = nil
+	default:
+		return nil, prompt.ErrUnknownOption
+	}
+
+	return append(next, a), nil
+}
@@ -0,0 +1,201 @@
+package rewards
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/classes"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/monsters"
+	"github.com/sleep2death/hexcore/potions"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	for id, rarity := range map[string]library.Rarity{
+		"rew_c1": library.Common, "rew_c2": library.Common, "rew_c3": library.Common,
+		"rew_u1": library.Uncommon, "rew_u2": library.Uncommon,
+		"rew_r1": library.Rare, "rew_strike": library.Basic,
+	} {
+		library.Define(&library.Def{ID: id, Name: id, Type: library.Attack, Rarity: rarity})
+	}
+	library.Define(&library.Def{ID: "rew_wound", Name: "Wound", Type: library.Status, Rarity: library.Common})
+	library.Define(&library.Def{ID: "rew_zap", Name: "Zap", Type: library.Skill, Rarity: library.Common, Color: "blue"})
+	library.Define(&library.Def{ID: "rew_ball", Name: "Ball", Type: library.Skill, Rarity: library.Rare, Color: "blue"})
+	classes.Define(&classes.Def{ID: "rew_defect", HP: 75, Color: "blue"})
+
+	potions.Define(&potions.Def{ID: "rew_potion", Rarity: library.Common,
+		Effects: []effects.Effect{{Op: effects.Heal, Amount: 5}}})
+	relics.Define(&relics.Def{ID: "rew_relic", Rarity: library.Common})
+	relics.Define(&relics.Def{ID: "rew_starter", Rarity: library.Basic})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, b := run.New("a", 42), run.New("b", 42)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, Generate(a, monsters.Normal), Generate(b, monsters.Normal))
+	}
+	assert.Equal(t, a.Counters, b.Counters)
+
+	r := run.New("r", 1)
+	for i := 0; i < 20; i++ {
+		pity := r.Counters[pityCounter]
+		rw := Generate(r, monsters.Normal)
+		assert.True(t, rw.Gold >= 10 && rw.Gold <= 20)
+		assert.Equal(t, "", rw.Relic)
+
+		// different cards, no basic or status card
+		assert.Len(t, rw.Cards, Choices)
+		assert.NotEqual(t, rw.Cards[0], rw.Cards[1])
+		assert.NotEqual(t, rw.Cards[1], rw.Cards[2])
+		assert.NotContains(t, rw.Cards, "rew_strike")
+		assert.NotContains(t, rw.Cards, "rew_wound")
+
+		// the pity counter raises with the common cards, and it's reset by the rare one
+		commons, rares := 0, 0
+		for _, id := range rw.Cards {
+			switch rarity(id) {
+			case library.Common:
+				commons++
+			case library.Rare:
+				rares++
+			}
+		}
+		if rares == 0 {
+			assert.Equal(t, pity+commons*PityStep, r.Counters[pityCounter])
+		}
+	}
+
+	// the boss offers the rare card first, the others are fallbacks
+	rw := Generate(r, monsters.Boss)
+	assert.Equal(t, library.Rare, rarity(rw.Cards[0]))
+	assert.Len(t, rw.Cards, Choices)
+	assert.Equal(t, 0, r.Counters[pityCounter])
+
+	// the elite drops a relic not owned
+	assert.Equal(t, "rew_relic", Generate(r, monsters.Elite).Relic)
+	r.State.Update(func(tx *store.Tx) error {
+		return tx.AddRelic("rew_relic")
+	})
+	assert.Equal(t, "", Generate(r, monsters.Elite).Relic)
+}
+
+func TestPotionChance(t *testing.T) {
+	for _, value := range []int{0, 100} {
+		remove := run.Modify(func(r *run.Run, name string, v int) int {
+			if name == PotionValue {
+				return value
+			}
+			return v
+		})
+
+		r := run.New("potion", 5)
+		for i := 0; i < 20; i++ {
+			Generate(r, monsters.Normal)
+			chance := potions.DropChance + r.Counters[potionCounter]
+			assert.True(t, chance >= 0 && chance <= 100, chance)
+		}
+		// the chance is pushed to the bound by the modified rolls
+		assert.Equal(t, 100-value, potions.DropChance+r.Counters[potionCounter])
+		remove()
+	}
+}
+
+func TestScreen(t *testing.T) {
+	r := run.New("screen", 3)
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+
+	// the potion is always dropped, but the slots are full
+	r.Counters[potionCounter] = 100
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetPotionSlots(1)
+		_, err := tx.AddPotion("rew_potion")
+		return err
+	})
+
+	assert.Nil(t, execute(ctx, &Offer{Kind: monsters.Elite}))
+	assert.Equal(t, []string{"prompt"}, events(outc))
+
+	p := prompt.Pending(ctx)
+	rw := p.Data.(*Reward)
+	assert.Equal(t, "rew_potion", rw.Potion)
+	assert.Equal(t, "rew_relic", rw.Relic)
+	o, _ := p.Option("potion")
+	assert.Equal(t, "potion slots are full", o.Disabled)
+	assert.Len(t, p.Options, 3+Choices+1)
+
+	card, other := rw.Cards[1], rw.Cards[0]
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "card:" + card}))
+	assert.Equal(t, []string{"card_obtained", "prompt"}, events(outc))
+	deck := r.State.GetPile(store.Deck)
+	assert.Equal(t, card, deck[0].Type())
+
+	// only one card can be taken
+	_, ok := prompt.Pending(ctx).Option("card:" + other)
+	assert.False(t, ok)
+
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "relic"}))
+	assert.Equal(t, []store.Relic{{ID: "rew_relic"}}, r.State.Relics())
+	assert.Equal(t, []string{"relic_obtained", "prompt"}, events(outc))
+
+	gold := rw.Gold
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "skip"}))
+	assert.Equal(t, []string{"reward_done"}, events(outc))
+	assert.Equal(t, 0, r.State.Gold())
+	assert.True(t, gold >= 25)
+	assert.Nil(t, prompt.Pending(ctx))
+
+	// no run attached
+	ctx = actions.NewContext(nil, outc, store.GetStore().AddState(&store.State{}))
+	assert.Equal(t, run.ErrNoRun, execute(ctx, &Offer{Kind: monsters.Normal}))
+}
+
+func TestClassPool(t *testing.T) {
+	// only the cards of the class color are offered
+	r, err := classes.New("rew_defect", "class", 5)
+	assert.Nil(t, err)
+	rw := Generate(r, monsters.Normal)
+	assert.Len(t, rw.Cards, 2)
+	assert.Contains(t, rw.Cards, "rew_zap")
+	assert.Contains(t, rw.Cards, "rew_ball")
+}
+
+func rarity(id string) library.Rarity {
+	def, _ := library.Get(id)
+	return def.Rarity
+}
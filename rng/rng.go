@@ -0,0 +1,80 @@
+package rng
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Position of the random sequence, which can be saved and restored
+type Position struct {
+	Seed int64  `json:"seed"`
+	Pos  uint64 `json:"pos"`
+}
+
+// Source is a math/rand source which remembers its seed
+// and how many values have been drawn from it,
+// so the random sequence can be saved and rewound to a certain position
+type Source struct {
+	seed int64
+	pos  uint64
+	src  rand.Source64
+}
+
+// NewSource with the seed
+func NewSource(seed int64) *Source {
+	return &Source{
+		seed: seed,
+		src:  rand.NewSource(seed).(rand.Source64),
+	}
+}
+
+// Int63 -
+func (s *Source) Int63() int64 {
+	s.pos++
+	return s.src.Int63()
+}
+
+// Uint64 -
+func (s *Source) Uint64() uint64 {
+	s.pos++
+	return s.src.Uint64()
+}
+
+// Seed -
+func (s *Source) Seed(seed int64) {
+	s.seed = seed
+	s.pos = 0
+	s.src.Seed(seed)
+}
+
+// Seek re-seeds the source and skips the first pos values
+func (s *Source) Seek(pos uint64) {
+	if pos == s.pos {
+		return
+	}
+
+	s.Seed(s.seed)
+	for s.pos < pos {
+		s.Int63()
+	}
+}
+
+// Position of the source
+func (s *Source) Position() Position {
+	return Position{Seed: s.seed, Pos: s.pos}
+}
+
+// Source returns a new source at the position
+func (p Position) Source() *Source {
+	s := NewSource(p.Seed)
+	s.Seek(p.Pos)
+	return s
+}
+
+// Derive a seed for the named stream from the base seed,
+// so different streams won't affect each other
+func Derive(seed int64, stream string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(stream))
+	return seed ^ int64(h.Sum64())
+}
@@ -0,0 +1,32 @@
+package rng
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	s := NewSource(7)
+	r := rand.New(s)
+
+	r.Intn(10)
+	r.Shuffle(5, func(i, j int) {})
+	p := s.Position()
+	assert.Equal(t, int64(7), p.Seed)
+
+	a := []int{r.Int(), r.Intn(100), r.Intn(3)}
+
+	// continues from the saved position
+	r = rand.New(p.Source())
+	assert.Equal(t, a, []int{r.Int(), r.Intn(100), r.Intn(3)})
+
+	// rewinds to the saved position
+	s.Seek(p.Pos)
+	r = rand.New(s)
+	assert.Equal(t, a, []int{r.Int(), r.Intn(100), r.Intn(3)})
+
+	assert.NotEqual(t, Derive(7, "map"), Derive(7, "cards"))
+	assert.Equal(t, Derive(7, "map"), Derive(7, "map"))
+}
@@ -0,0 +1,252 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WithDoc describes the route for the introspection
+func WithDoc(doc string) RouteOption {
+	return func(rt *route) {
+		rt.doc = doc
+	}
+}
+
+// WithPayload declares the payload of the route by an example value,
+// e.g. WithPayload(PlayCard{}), its JSON schema will be generated in the description
+func WithPayload(v interface{}) RouteOption {
+	return func(rt *route) {
+		rt.payload = reflect.TypeOf(v)
+	}
+}
+
+// ParamInfo of the route
+type ParamInfo struct {
+	Name     string
+	Type     ParamType
+	CatchAll bool
+}
+
+// RouteInfo is the registered route
+type RouteInfo struct {
+	Type       ActionType
+	Path       string
+	Params     []ParamInfo
+	Doc        string
+	Payload    reflect.Type
+	Deprecated string
+}
+
+// Routes walks the trees, and returns all the registered routes,
+// sorted by the action type and the path
+func (r *Router) Routes() []RouteInfo {
+	var infos []RouteInfo
+
+	t := r.load()
+	for group, root := range t.trees {
+		root.walk("", func(path string, _ Handle) {
+			info := RouteInfo{
+				Type: ActionType(group),
+				Path: path,
+			}
+
+			rt := t.lookup(group, path)
+			info.Doc = rt.doc
+			info.Payload = rt.payload
+			info.Deprecated = rt.deprecated
+
+			for _, seg := range strings.Split(path, "/") {
+				if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+					continue
+				}
+
+				p := ParamInfo{Name: seg[1:], Type: String, CatchAll: seg[0] == '*'}
+				if pt, ok := rt.schema[p.Name]; ok {
+					p.Type = pt
+				}
+				info.Params = append(info.Params, p)
+			}
+
+			infos = append(infos, info)
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Type != infos[j].Type {
+			return infos[i].Type < infos[j].Type
+		}
+		return infos[i].Path < infos[j].Path
+	})
+	return infos
+}
+
+// Description of the protocol, which can be used for generating the client bindings
+type Description struct {
+	Version    int                `json:"version,omitempty"`
+	Deprecated string             `json:"deprecated,omitempty"`
+	Routes     []RouteDescription `json:"routes"`
+}
+
+// RouteDescription -
+type RouteDescription struct {
+	Type       ActionType             `json:"type"`
+	Path       string                 `json:"path"`
+	Doc        string                 `json:"doc,omitempty"`
+	Deprecated string                 `json:"deprecated,omitempty"`
+	Params     []ParamDescription     `json:"params,omitempty"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+}
+
+// ParamDescription -
+type ParamDescription struct {
+	Name     string                 `json:"name"`
+	CatchAll bool                   `json:"catch_all,omitempty"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+// Describe the protocol of the router
+func (r *Router) Describe() *Description {
+	d := &Description{Routes: make([]RouteDescription, 0)}
+
+	for _, info := range r.Routes() {
+		rd := RouteDescription{
+			Type:       info.Type,
+			Path:       info.Path,
+			Doc:        info.Doc,
+			Deprecated: info.Deprecated,
+		}
+
+		for _, p := range info.Params {
+			rd.Params = append(rd.Params, ParamDescription{
+				Name:     p.Name,
+				CatchAll: p.CatchAll,
+				Schema:   paramSchema(p.Type),
+			})
+		}
+
+		if info.Payload != nil {
+			rd.Payload = typeSchema(info.Payload, make(map[reflect.Type]bool))
+		}
+
+		d.Routes = append(d.Routes, rd)
+	}
+
+	return d
+}
+
+// JSON of the description, indented
+func (d *Description) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// paramSchema is the JSON schema of the param type
+func paramSchema(pt ParamType) map[string]interface{} {
+	if pt.Values != nil {
+		return map[string]interface{}{"type": "string", "enum": pt.Values}
+	}
+
+	switch pt.Name {
+	case Int.Name:
+		return map[string]interface{}{"type": "integer"}
+	case Uint.Name:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case Bool.Name:
+		return map[string]interface{}{"type": "boolean"}
+	case ID.Name:
+		return map[string]interface{}{"type": "string", "pattern": idPattern.String()}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// typeSchema is the JSON schema of the go type, which follows the encoding/json rules
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		// recursive type
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := make(map[string]interface{})
+		required := make([]string, 0)
+		structSchema(t, seen, props, &required)
+
+		s := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+
+	// interface, func, etc.
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := f.Name
+		omitempty := false
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitempty = omitempty || opt == "omitempty"
+			}
+		}
+
+		// embedded struct without tag, its fields are promoted
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structSchema(ft, seen, props, required)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		props[name] = typeSchema(f.Type, seen)
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
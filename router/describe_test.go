@@ -0,0 +1,101 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+type playPayload struct {
+	Target  string       `json:"target"`
+	Choices []int        `json:"choices,omitempty"`
+	Seq     uint64       `json:"seq"`
+	Ignored string       `json:"-"`
+	Next    *playPayload `json:"next,omitempty"`
+	secret  string
+}
+
+func nopHandle(req *Request, ps Params) (actions.Action, error) {
+	return nil, nil
+}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/card/play/:id<id>", nopHandle, WithDoc("play a card"), WithPayload(playPayload{}))
+	r.Handle(Card, "/card/discard/:id", nopHandle)
+	r.Handle(Battle, "/target/:idx<uint>/:pile<draw|hand>", nopHandle)
+	r.Handle(Normal, "/files/*path", nopHandle)
+
+	routes := r.Routes()
+	assert.Equal(t, 4, len(routes))
+
+	assert.Equal(t, Battle, routes[0].Type)
+	assert.Equal(t, "/target/:idx/:pile", routes[0].Path)
+	assert.Equal(t, "idx", routes[0].Params[0].Name)
+	assert.Equal(t, "uint", routes[0].Params[0].Type.Name)
+	assert.Equal(t, "pile", routes[0].Params[1].Name)
+	assert.Equal(t, []string{"draw", "hand"}, routes[0].Params[1].Type.Values)
+
+	assert.Equal(t, Card, routes[1].Type)
+	assert.Equal(t, "/card/discard/:id", routes[1].Path)
+	assert.Equal(t, "string", routes[1].Params[0].Type.Name)
+
+	assert.Equal(t, "/card/play/:id", routes[2].Path)
+	assert.Equal(t, "id", routes[2].Params[0].Type.Name)
+	assert.Equal(t, "play a card", routes[2].Doc)
+	assert.Equal(t, reflect.TypeOf(playPayload{}), routes[2].Payload)
+
+	assert.Equal(t, Normal, routes[3].Type)
+	assert.Equal(t, "/files/*path", routes[3].Path)
+	assert.Equal(t, "path", routes[3].Params[0].Name)
+	assert.True(t, routes[3].Params[0].CatchAll)
+}
+
+func TestDescribe(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/card/play/:id<id>", nopHandle, WithDoc("play a card"), WithPayload(&playPayload{}))
+	r.Handle(Battle, "/target/:idx<int>/:pile<draw|hand>", nopHandle)
+
+	data, err := r.Describe().JSON()
+	assert.Nil(t, err)
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &doc))
+
+	expected := `{
+	"routes": [
+		{
+			"type": "Battle",
+			"path": "/target/:idx/:pile",
+			"params": [
+				{"name": "idx", "schema": {"type": "integer"}},
+				{"name": "pile", "schema": {"type": "string", "enum": ["draw", "hand"]}}
+			]
+		},
+		{
+			"type": "Card",
+			"path": "/card/play/:id",
+			"doc": "play a card",
+			"params": [
+				{"name": "id", "schema": {"type": "string", "pattern": "^[A-Za-z0-9_\\-:.]+$"}}
+			],
+			"payload": {
+				"type": "object",
+				"properties": {
+					"target": {"type": "string"},
+					"choices": {"type": "array", "items": {"type": "integer"}},
+					"seq": {"type": "integer", "minimum": 0},
+					"next": {"type": "object"}
+				},
+				"required": ["target", "seq"]
+			}
+		}
+	]
+}`
+	var exp map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(expected), &exp))
+	assert.Equal(t, exp, doc)
+}
@@ -0,0 +1,126 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/sleep2death/hexcore/actions"
+)
+
+// Middleware wraps the Handle, it can run code before and after the action is constructed,
+// replace the handle, or wrap the produced action, e.g. for logging the execution of the action.
+// Middlewares are composed like the HTTP ones: global first, then the group ones,
+// then the route ones, the first middleware is the outermost one.
+type Middleware func(next Handle) Handle
+
+// RouteOption configures the route when it's registered
+type RouteOption func(rt *route)
+
+// route options
+type route struct {
+	group string
+	path  string
+	// handle with the validation and the route middlewares
+	handle Handle
+
+	middlewares []Middleware
+	schema      Schema
+
+	// metadata for the introspection
+	doc        string
+	payload    reflect.Type
+	deprecated string
+}
+
+// WithMiddleware adds the middlewares to the route
+func WithMiddleware(mws ...Middleware) RouteOption {
+	return func(rt *route) {
+		rt.middlewares = append(rt.middlewares, mws...)
+	}
+}
+
+// chain the middlewares around the handle
+func chain(handle Handle, mws []Middleware) Handle {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handle = mws[i](handle)
+	}
+	return handle
+}
+
+// wrapped action of the middlewares, it forwards the Undoable of the inner action,
+// so the undo checkpoint is still taken for the wrapped input
+type wrapped struct {
+	actions.ActionFunc
+	inner actions.Action
+}
+
+// wrap the inner action with the func
+func wrap(inner actions.Action, fn actions.ActionFunc) actions.Action {
+	return &wrapped{ActionFunc: fn, inner: inner}
+}
+
+// Undoable -
+func (w *wrapped) Undoable() bool {
+	u, ok := w.inner.(actions.Undoable)
+	return ok && u.Undoable()
+}
+
+// Recovery middleware recovers the panics from the handle and the action it produced,
+// fn returns the action which replaces the panicked one, nil value is allowed
+func Recovery(fn func(v interface{}) actions.Action) Middleware {
+	return func(next Handle) Handle {
+		return func(req *Request, ps Params) (action actions.Action, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					action, err = fn(v), nil
+				}
+			}()
+
+			produced, err := next(req, ps)
+			if produced == nil || err != nil {
+				return produced, err
+			}
+
+			return wrap(produced, func(ctx *actions.Context) (res []actions.Action, err error) {
+				defer func() {
+					if v := recover(); v != nil {
+						res, err = nil, nil
+						if a := fn(v); a != nil {
+							res = []actions.Action{a}
+						}
+					}
+				}()
+				return produced.Exec(ctx)
+			}), nil
+		}
+	}
+}
+
+// Logger middleware logs the request and the execution of the produced action
+func Logger(logf func(format string, v ...interface{})) Middleware {
+	return func(next Handle) Handle {
+		return func(req *Request, ps Params) (actions.Action, error) {
+			action, err := next(req, ps)
+			if err != nil {
+				logf("route %s %s%v: %v", req.Type, req.Path, ps, err)
+				return nil, err
+			}
+
+			if action == nil {
+				logf("route %s %s%v: no action", req.Type, req.Path, ps)
+				return nil, nil
+			}
+
+			name := fmt.Sprintf("%T", action)
+			logf("route %s %s%v: %s", req.Type, req.Path, ps, name)
+
+			return wrap(action, func(ctx *actions.Context) ([]actions.Action, error) {
+				start := time.Now()
+				res, err := action.Exec(ctx)
+				logf("action %s: %v, error: %v", name, time.Since(start), err)
+				return res, err
+			}), nil
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func record(trace *[]string, name string) Middleware {
+	return func(next Handle) Handle {
+		return func(req *Request, ps Params) (actions.Action, error) {
+			*trace = append(*trace, name+">")
+			action, err := next(req, ps)
+			*trace = append(*trace, "<"+name)
+			return action, err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+
+	r := New()
+	r.Use(record(&trace, "global"))
+	r.Handle(Card, "/play/:id", func(req *Request, ps Params) (actions.Action, error) {
+		trace = append(trace, "handle "+ps.ByName("id"))
+		return &actions.OutputString{}, nil
+	}, WithMiddleware(record(&trace, "route1"), record(&trace, "route2")))
+	r.Handle(Battle, "/end", func(req *Request, ps Params) (actions.Action, error) {
+		trace = append(trace, "end")
+		return &actions.OutputString{}, nil
+	})
+
+	// registered after the routes, still works
+	r.UseGroup(Card, record(&trace, "card"))
+
+	action, _ := r.Serve(&Request{Type: Card, Path: "/play/a"})
+	assert.NotNil(t, action)
+	assert.Equal(t, []string{"global>", "card>", "route1>", "route2>", "handle a", "<route2", "<route1", "<card", "<global"}, trace)
+
+	trace = nil
+	action, _ = r.Serve(&Request{Type: Battle, Path: "/end"})
+	assert.NotNil(t, action)
+	assert.Equal(t, []string{"global>", "end", "<global"}, trace)
+}
+
+func TestRecovery(t *testing.T) {
+	recovered := &actions.OutputString{Message: "recovered"}
+
+	r := New()
+	r.Use(Recovery(func(v interface{}) actions.Action {
+		recovered.Message = fmt.Sprint("recovered: ", v)
+		return recovered
+	}))
+
+	r.Handle(Card, "/handle", func(req *Request, ps Params) (actions.Action, error) {
+		panic("handle")
+	})
+	r.Handle(Card, "/exec", func(req *Request, ps Params) (actions.Action, error) {
+		return actions.ActionFunc(func(ctx *actions.Context) ([]actions.Action, error) {
+			panic("exec")
+		}), nil
+	})
+
+	action, err := r.Serve(&Request{Type: Card, Path: "/handle"})
+	assert.Nil(t, err)
+	assert.Equal(t, recovered, action)
+	assert.Equal(t, "recovered: handle", recovered.Message)
+
+	action, _ = r.Serve(&Request{Type: Card, Path: "/exec"})
+	next, err := action.Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []actions.Action{recovered}, next)
+	assert.Equal(t, "recovered: exec", recovered.Message)
+}
+
+func TestLogger(t *testing.T) {
+	var logs []string
+
+	r := New()
+	r.UseGroup(Card, Logger(func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}))
+	r.Handle(Card, "/play/:id", func(req *Request, ps Params) (actions.Action, error) {
+		return actions.ActionFunc(func(ctx *actions.Context) ([]actions.Action, error) {
+			return nil, nil
+		}), nil
+	})
+
+	action, _ := r.Serve(&Request{Type: Card, Path: "/play/a"})
+	action.Exec(nil)
+	assert.Equal(t, 2, len(logs))
+	assert.Equal(t, "route Card /play/a[{id a}]: actions.ActionFunc", logs[0])
+	assert.True(t, strings.HasPrefix(logs[1], "action actions.ActionFunc: "))
+}
+
+type undoable struct {
+	actions.OutputString
+}
+
+func (a *undoable) Undoable() bool {
+	return true
+}
+
+func TestUndoable(t *testing.T) {
+	r := New()
+	r.Use(Recovery(func(v interface{}) actions.Action { return nil }))
+	r.Use(Logger(func(format string, v ...interface{}) {}))
+	r.Handle(Card, "/play/:id", func(req *Request, ps Params) (actions.Action, error) {
+		return &undoable{}, nil
+	}, Deprecated("use /card/play/:id"))
+	r.Handle(Battle, "/end", func(req *Request, ps Params) (actions.Action, error) {
+		return &actions.OutputString{}, nil
+	})
+
+	// the wrapped action is still undoable
+	action, err := r.Serve(&Request{Type: Card, Path: "/play/a"})
+	assert.Nil(t, err)
+	u, ok := action.(actions.Undoable)
+	assert.True(t, ok)
+	assert.True(t, u.Undoable())
+
+	action, err = r.Serve(&Request{Type: Battle, Path: "/end"})
+	assert.Nil(t, err)
+	u, ok = action.(actions.Undoable)
+	assert.True(t, !ok || !u.Undoable())
+}
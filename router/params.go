@@ -0,0 +1,234 @@
+package router
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sleep2death/hexcore/actions"
+)
+
+var (
+	// ErrParamMissing -
+	ErrParamMissing = errors.New("param is missing")
+	// ErrParamInvalid -
+	ErrParamInvalid = errors.New("param is invalid")
+)
+
+// ParamError is returned when the param is missing or can't be parsed as its type
+type ParamError struct {
+	Name  string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return "param '" + e.Name + "' (" + e.Type + ") with value '" + e.Value + "': " + e.Err.Error()
+}
+
+// ParamType validates the value of the param
+type ParamType struct {
+	Name     string
+	Validate func(value string) error
+	// Values of the enum type
+	Values []string
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_\-:.]+$`)
+
+var (
+	// String param, any value is valid
+	String = ParamType{Name: "string", Validate: func(string) error { return nil }}
+
+	// Int param
+	Int = ParamType{Name: "int", Validate: func(v string) error {
+		_, err := strconv.Atoi(v)
+		return err
+	}}
+
+	// Uint param
+	Uint = ParamType{Name: "uint", Validate: func(v string) error {
+		_, err := strconv.ParseUint(v, 10, 0)
+		return err
+	}}
+
+	// Bool param
+	Bool = ParamType{Name: "bool", Validate: func(v string) error {
+		_, err := strconv.ParseBool(v)
+		return err
+	}}
+
+	// ID param, e.g. a card id or an actor id
+	ID = ParamType{Name: "id", Validate: func(v string) error {
+		if !idPattern.MatchString(v) {
+			return ErrParamInvalid
+		}
+		return nil
+	}}
+)
+
+// Enum param, the value must be one of the given values
+func Enum(values ...string) ParamType {
+	return ParamType{
+		Name:   strings.Join(values, "|"),
+		Values: values,
+		Validate: func(v string) error {
+			for _, value := range values {
+				if v == value {
+					return nil
+				}
+			}
+			return ErrParamInvalid
+		},
+	}
+}
+
+var paramTypes = map[string]ParamType{
+	String.Name: String,
+	Int.Name:    Int,
+	Uint.Name:   Uint,
+	Bool.Name:   Bool,
+	ID.Name:     ID,
+}
+
+// Schema of the route params, by the param name
+type Schema map[string]ParamType
+
+// WithParams declares the types of the route params,
+// it's the same as declaring them in the path, e.g. /target/:idx<int>
+func WithParams(schema Schema) RouteOption {
+	return func(rt *route) {
+		if rt.schema == nil {
+			rt.schema = make(Schema)
+		}
+
+		for name, pt := range schema {
+			rt.schema[name] = pt
+		}
+	}
+}
+
+// parseTypes removes the type declarations from the path,
+// and returns them as the schema: /target/:idx<int>/:pile<draw|hand>
+func parseTypes(path string) (string, Schema) {
+	var schema Schema
+	var buf strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		buf.WriteByte(c)
+		if c != ':' && c != '*' {
+			continue
+		}
+
+		// wildcard name ends at '<', '/' or the path end
+		start := i + 1
+		end := start
+		for end < len(path) && path[end] != '<' && path[end] != '/' {
+			end++
+		}
+		buf.WriteString(path[start:end])
+		i = end - 1
+
+		if end == len(path) || path[end] != '<' {
+			continue
+		}
+
+		closing := strings.IndexByte(path[end:], '>')
+		if closing < 0 {
+			panic("unclosed param type in path '" + path + "'")
+		}
+
+		name := path[start:end]
+		typ := path[end+1 : end+closing]
+		pt, ok := paramTypes[typ]
+		if !ok {
+			if !strings.Contains(typ, "|") {
+				panic("unknown param type '" + typ + "' in path '" + path + "'")
+			}
+			pt = Enum(strings.Split(typ, "|")...)
+		}
+
+		if schema == nil {
+			schema = make(Schema)
+		}
+		schema[name] = pt
+		i = end + closing
+	}
+
+	return buf.String(), schema
+}
+
+// validate the params before the handle is called
+func validate(schema Schema, next Handle) Handle {
+	return func(req *Request, ps Params) (actions.Action, error) {
+		for _, p := range ps {
+			pt, ok := schema[p.Key]
+			if !ok {
+				continue
+			}
+
+			if err := pt.Validate(p.Value); err != nil {
+				return nil, &ParamError{Name: p.Key, Value: p.Value, Type: pt.Name, Err: ErrParamInvalid}
+			}
+		}
+		return next(req, ps)
+	}
+}
+
+func (ps Params) get(name string, pt ParamType) (string, error) {
+	for i := range ps {
+		if ps[i].Key == name {
+			if err := pt.Validate(ps[i].Value); err != nil {
+				return ps[i].Value, &ParamError{Name: name, Value: ps[i].Value, Type: pt.Name, Err: ErrParamInvalid}
+			}
+			return ps[i].Value, nil
+		}
+	}
+	return "", &ParamError{Name: name, Type: pt.Name, Err: ErrParamMissing}
+}
+
+// String returns the value of the param, error if it's missing
+func (ps Params) String(name string) (string, error) {
+	return ps.get(name, String)
+}
+
+// Int returns the value of the param as int
+func (ps Params) Int(name string) (int, error) {
+	v, err := ps.get(name, Int)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(v)
+}
+
+// Uint returns the value of the param as uint
+func (ps Params) Uint(name string) (uint, error) {
+	v, err := ps.get(name, Uint)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(v, 10, 0)
+	return uint(n), err
+}
+
+// Bool returns the value of the param as bool
+func (ps Params) Bool(name string) (bool, error) {
+	v, err := ps.get(name, Bool)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(v)
+}
+
+// ID returns the value of the param as an id
+func (ps Params) ID(name string) (string, error) {
+	return ps.get(name, ID)
+}
+
+// Enum returns the value of the param, it must be one of the values
+func (ps Params) Enum(name string, values ...string) (string, error) {
+	return ps.get(name, Enum(values...))
+}
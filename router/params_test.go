@@ -0,0 +1,97 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTypes(t *testing.T) {
+	path, schema := parseTypes("/target/:idx<int>/:pile<draw|hand>/:name/*rest<string>")
+	assert.Equal(t, "/target/:idx/:pile/:name/*rest", path)
+	assert.Equal(t, 3, len(schema))
+	assert.Equal(t, "int", schema["idx"].Name)
+	assert.Equal(t, "draw|hand", schema["pile"].Name)
+	assert.Equal(t, "string", schema["rest"].Name)
+
+	path, schema = parseTypes("/strike/:card_id/:target_id")
+	assert.Equal(t, "/strike/:card_id/:target_id", path)
+	assert.Nil(t, schema)
+
+	assert.Panics(t, func() { parseTypes("/target/:idx<int") })
+	assert.Panics(t, func() { parseTypes("/target/:idx<float>") })
+}
+
+func TestTypedParams(t *testing.T) {
+	called := 0
+	handle := func(req *Request, ps Params) (actions.Action, error) {
+		called++
+
+		idx, err := ps.Int("idx")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, idx)
+
+		pile, err := ps.Enum("pile", "draw", "hand")
+		assert.Nil(t, err)
+		assert.Equal(t, "hand", pile)
+
+		id, err := ps.ID("id")
+		assert.Nil(t, err)
+		assert.Equal(t, "card-1", id)
+		return nil, nil
+	}
+
+	r := New()
+	r.Handle(Card, "/target/:idx<int>/:pile<draw|hand>/:id", handle, WithParams(Schema{"id": ID}))
+
+	_, err := r.Serve(&Request{Type: Card, Path: "/target/2/hand/card-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, called)
+
+	for _, c := range []struct {
+		path string
+		err  *ParamError
+	}{
+		{"/target/x/hand/card-1", &ParamError{Name: "idx", Value: "x", Type: "int", Err: ErrParamInvalid}},
+		{"/target/2/deck/card-1", &ParamError{Name: "pile", Value: "deck", Type: "draw|hand", Err: ErrParamInvalid}},
+		{"/target/2/draw/card%201", &ParamError{Name: "id", Value: "card%201", Type: "id", Err: ErrParamInvalid}},
+	} {
+		action, err := r.Serve(&Request{Type: Card, Path: c.path})
+		assert.Nil(t, action)
+		assert.Equal(t, c.err, err)
+	}
+
+	// rejected before the handle is called
+	assert.Equal(t, 1, called)
+}
+
+func TestParamAccessors(t *testing.T) {
+	ps := Params{{"n", "-3"}, {"u", "4"}, {"b", "true"}, {"s", "x y"}}
+
+	n, err := ps.Int("n")
+	assert.Equal(t, -3, n)
+	assert.Nil(t, err)
+
+	u, err := ps.Uint("u")
+	assert.Equal(t, uint(4), u)
+	assert.Nil(t, err)
+
+	_, err = ps.Uint("n")
+	assert.Equal(t, &ParamError{Name: "n", Value: "-3", Type: "uint", Err: ErrParamInvalid}, err)
+
+	b, err := ps.Bool("b")
+	assert.True(t, b)
+	assert.Nil(t, err)
+
+	s, err := ps.String("s")
+	assert.Equal(t, "x y", s)
+	assert.Nil(t, err)
+
+	_, err = ps.ID("s")
+	assert.Equal(t, ErrParamInvalid, err.(*ParamError).Err)
+
+	_, err = ps.Int("missing")
+	assert.Equal(t, &ParamError{Name: "missing", Type: "int", Err: ErrParamMissing}, err)
+	assert.Equal(t, "param 'missing' (int) with value '': param is missing", err.Error())
+}
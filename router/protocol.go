@@ -0,0 +1,192 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+)
+
+var (
+	// ErrUnsupportedVersion -
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+	// ErrNoCommonVersion -
+	ErrNoCommonVersion = errors.New("no mutually supported protocol version")
+)
+
+// Message is the envelope of the client request
+type Message struct {
+	Version int             `json:"version"`
+	Type    ActionType      `json:"type"`
+	Path    string          `json:"path"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Seq     uint64          `json:"seq"`
+}
+
+// Hello is sent by the client for the handshake, with all the versions it supports
+type Hello struct {
+	Versions []int `json:"versions"`
+}
+
+// Welcome is the handshake reply, with the negotiated version
+type Welcome struct {
+	Version    int    `json:"version"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Deprecated marks the route as deprecated,
+// a warning will be sent to the output before the action is executed
+func Deprecated(msg string) RouteOption {
+	return func(rt *route) {
+		rt.deprecated = msg
+	}
+}
+
+// Warning action sends the deprecation warning to the output
+type Warning struct {
+	Type    ActionType `json:"type"`
+	Path    string     `json:"path"`
+	Version int        `json:"version"`
+	Message string     `json:"message"`
+}
+
+// Exec -
+func (a *Warning) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	data, err := json.Marshal(struct {
+		Event string `json:"event"`
+		*Warning
+	}{"deprecated", a})
+	if err != nil {
+		return nil, err
+	}
+	return []actions.Action{&actions.OutputString{Message: string(data)}}, nil
+}
+
+// deprecate the handle, the produced action will be preceded by the warning
+func deprecate(msg string, next Handle) Handle {
+	return func(req *Request, ps Params) (actions.Action, error) {
+		action, err := next(req, ps)
+		if action == nil || err != nil {
+			return action, err
+		}
+
+		warning := &Warning{Type: req.Type, Path: req.Path, Version: req.Version, Message: msg}
+		return wrap(action, func(ctx *actions.Context) ([]actions.Action, error) {
+			return []actions.Action{warning, action}, nil
+		}), nil
+	}
+}
+
+// Protocol dispatches the messages to the route sets of their versions
+type Protocol struct {
+	mu         sync.RWMutex
+	versions   map[int]*Router
+	deprecated map[int]string
+}
+
+// NewProtocol -
+func NewProtocol() *Protocol {
+	return &Protocol{
+		versions:   make(map[int]*Router),
+		deprecated: make(map[int]string),
+	}
+}
+
+// Version returns the router of the version, it's created if not exist
+func (p *Protocol) Version(v int) *Router {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok := p.versions[v]
+	if !ok {
+		r = New()
+		p.versions[v] = r
+	}
+	return r
+}
+
+// Deprecate the whole version, the warning will be sent for every message of it
+func (p *Protocol) Deprecate(v int, msg string) {
+	p.mu.Lock()
+	p.deprecated[v] = msg
+	p.mu.Unlock()
+}
+
+// Versions supported, sorted
+func (p *Protocol) Versions() []int {
+	p.mu.RLock()
+	vs := make([]int, 0, len(p.versions))
+	for v := range p.versions {
+		vs = append(vs, v)
+	}
+	p.mu.RUnlock()
+
+	sort.Ints(vs)
+	return vs
+}
+
+// Negotiate the highest version supported by both the client and the server
+func (p *Protocol) Negotiate(hello *Hello) (*Welcome, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	best := -1
+	for _, v := range hello.Versions {
+		if _, ok := p.versions[v]; ok && v > best {
+			best = v
+		}
+	}
+
+	if best < 0 {
+		return nil, ErrNoCommonVersion
+	}
+	return &Welcome{Version: best, Deprecated: p.deprecated[best]}, nil
+}
+
+// Dispatch the message of the session and the player to the router of its version
+func (p *Protocol) Dispatch(session, player string, msg *Message) (actions.Action, error) {
+	p.mu.RLock()
+	r, ok := p.versions[msg.Version]
+	deprecated := p.deprecated[msg.Version]
+	p.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnsupportedVersion
+	}
+
+	handle := func(req *Request, _ Params) (actions.Action, error) {
+		return r.Serve(req)
+	}
+	if deprecated != "" {
+		handle = deprecate("version "+strconv.Itoa(msg.Version)+" is deprecated: "+deprecated, handle)
+	}
+
+	return handle(&Request{
+		Type:    msg.Type,
+		Path:    msg.Path,
+		Version: msg.Version,
+		Session: session,
+		Player:  player,
+		Seq:     msg.Seq,
+		Payload: msg.Payload,
+	}, nil)
+}
+
+// Describe the route sets of all the versions
+func (p *Protocol) Describe() []*Description {
+	var ds []*Description
+	for _, v := range p.Versions() {
+		d := p.Version(v).Describe()
+		d.Version = v
+
+		p.mu.RLock()
+		d.Deprecated = p.deprecated[v]
+		p.mu.RUnlock()
+
+		ds = append(ds, d)
+	}
+	return ds
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProtocol() *Protocol {
+	p := NewProtocol()
+
+	v1 := p.Version(1)
+	v1.Handle(Card, "/play/:id", valueHandle("v1 play"), Deprecated("use /card/play/:id"))
+
+	v2 := p.Version(2)
+	v2.Handle(Card, "/card/play/:id", func(req *Request, ps Params) (actions.Action, error) {
+		var payload struct {
+			Target string `json:"target"`
+		}
+		if err := req.Decode(&payload); err != nil {
+			return nil, err
+		}
+		return &actions.OutputString{Message: req.Session + " " + req.Player + " " + ps.ByName("id") + " -> " + payload.Target}, nil
+	})
+
+	return p
+}
+
+func TestNegotiate(t *testing.T) {
+	p := newTestProtocol()
+	assert.Equal(t, []int{1, 2}, p.Versions())
+
+	w, err := p.Negotiate(&Hello{Versions: []int{1, 2, 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, &Welcome{Version: 2}, w)
+
+	p.Deprecate(1, "upgrade the client")
+	w, _ = p.Negotiate(&Hello{Versions: []int{0, 1}})
+	assert.Equal(t, &Welcome{Version: 1, Deprecated: "upgrade the client"}, w)
+
+	_, err = p.Negotiate(&Hello{Versions: []int{3}})
+	assert.Equal(t, ErrNoCommonVersion, err)
+}
+
+func TestDispatch(t *testing.T) {
+	p := newTestProtocol()
+
+	msg := &Message{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"version": 2, "type": "Card", "path": "/card/play/c1", "payload": {"target": "m1"}, "seq": 3}`), msg))
+
+	action, err := p.Dispatch("s1", "p1", msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "s1 p1 c1 -> m1", action.(*actions.OutputString).Message)
+
+	// routes are separated by the versions
+	_, err = p.Dispatch("s1", "p1", &Message{Version: 2, Type: Card, Path: "/play/c1"})
+	assert.Equal(t, ErrNotFound, err.(*RouteError).Err)
+
+	_, err = p.Dispatch("s1", "p1", &Message{Version: 3, Type: Card, Path: "/play/c1"})
+	assert.Equal(t, ErrUnsupportedVersion, err)
+}
+
+func TestDeprecation(t *testing.T) {
+	p := newTestProtocol()
+
+	// deprecated route
+	action, err := p.Dispatch("s1", "p1", &Message{Version: 1, Type: Card, Path: "/play/c1"})
+	assert.Nil(t, err)
+
+	next, err := action.Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(next))
+	assert.Equal(t, &Warning{Type: Card, Path: "/play/c1", Version: 1, Message: "use /card/play/:id"}, next[0])
+	assert.Equal(t, "v1 play", next[1].(*actions.OutputString).Message)
+
+	out, _ := next[0].Exec(nil)
+	assert.JSONEq(t, `{"event": "deprecated", "type": "Card", "path": "/play/c1", "version": 1, "message": "use /card/play/:id"}`, out[0].(*actions.OutputString).Message)
+
+	// deprecated version and route, both warnings are sent
+	p.Deprecate(1, "upgrade the client")
+	action, _ = p.Dispatch("s1", "p1", &Message{Version: 1, Type: Card, Path: "/play/c1"})
+	next, _ = action.Exec(nil)
+	assert.Equal(t, "version 1 is deprecated: upgrade the client", next[0].(*Warning).Message)
+
+	next, _ = next[1].Exec(nil)
+	assert.Equal(t, "use /card/play/:id", next[0].(*Warning).Message)
+
+	ds := p.Describe()
+	assert.Equal(t, 2, len(ds))
+	assert.Equal(t, 1, ds[0].Version)
+	assert.Equal(t, "upgrade the client", ds[0].Deprecated)
+	assert.Equal(t, "use /card/play/:id", ds[0].Routes[0].Deprecated)
+	assert.Equal(t, 2, ds[1].Version)
+}
@@ -1,6 +1,13 @@
 package router
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
 	"github.com/sleep2death/hexcore/actions"
 )
 
@@ -16,8 +23,78 @@ const (
 	Normal ActionType = "Normal"
 )
 
-// Handle is a function that can be registered to a route to handle Action
-type Handle func(Params) actions.Action
+var (
+	// ErrNoPayload -
+	ErrNoPayload = errors.New("request has no payload")
+	// ErrNotFound -
+	ErrNotFound = errors.New("route not found")
+	// ErrWrongType -
+	ErrWrongType = errors.New("route exists under a different action type")
+	// ErrRedirect -
+	ErrRedirect = errors.New("route exists with a corrected path")
+)
+
+// RouteError is returned by Serve, when no action can be served for the request
+type RouteError struct {
+	// Err is one of ErrNotFound, ErrWrongType and ErrRedirect
+	Err error
+	// Type and Path of the request
+	Type ActionType
+	Path string
+	// FixedPath is the corrected path, for ErrRedirect
+	FixedPath string
+	// Allowed action types of the path, for ErrWrongType
+	Allowed []ActionType
+}
+
+func (e *RouteError) Error() string {
+	msg := string(e.Type) + " " + e.Path + ": " + e.Err.Error()
+	switch e.Err {
+	case ErrRedirect:
+		msg += " " + e.FixedPath
+	case ErrWrongType:
+		for i, t := range e.Allowed {
+			if i == 0 {
+				msg += " " + string(t)
+			} else {
+				msg += ", " + string(t)
+			}
+		}
+	}
+	return msg
+}
+
+// Handle is a function that can be registered to a route to handle Action,
+// it returns the error if the action can't be constructed from the request
+type Handle func(*Request, Params) (actions.Action, error)
+
+// Request to the router, which is sent by the client
+type Request struct {
+	// Type of the action
+	Type ActionType
+	// Path of the action, e.g. /card/play/:id
+	Path string
+	// Version of the protocol, see Protocol
+	Version int
+
+	// Session id which the request targets
+	Session string
+	// Player id who sent the request
+	Player string
+	// Seq is the sequence number of the client request
+	Seq uint64
+
+	// Payload of the request, e.g. target, choice selections
+	Payload json.RawMessage
+}
+
+// Decode the payload into v
+func (req *Request) Decode(v interface{}) error {
+	if len(req.Payload) == 0 {
+		return ErrNoPayload
+	}
+	return json.Unmarshal(req.Payload, v)
+}
 
 // Param is a single URL parameter, consisting of a key and a value.
 type Param struct {
@@ -43,29 +120,38 @@ func (ps Params) ByName(name string) string {
 
 // Router can be used to dispatch requests to different
 // handler functions via configurable routes
+//
+// It's safe to register or remove the routes while serving,
+// the route table is copied on write, and swapped atomically,
+// so the in-flight serves keep using the table they started with.
 type Router struct {
-	trees map[string]*node
+	// writers lock
+	mu sync.Mutex
+	// current *table
+	table atomic.Value
 
-	// Enables automatic redirection if the current route can't be matched but a
+	// Enables the redirection suggestion if the current route can't be matched but a
 	// handler for the path with (without) the trailing slash exists.
-	// For example if /foo/ is requested but a route only exists for /foo, the
-	// client is redirected to /foo with http status code 301 for GET requests
-	// and 307 for all other request methods.
+	// For example if /foo/ is requested but a route only exists for /foo,
+	// Serve returns a RouteError with ErrRedirect, and /foo as the FixedPath,
+	// the client can send the request again with the corrected path.
 	RedirectTrailingSlash bool
 
 	// If enabled, the router tries to fix the current request path, if no
 	// handle is registered for it.
 	// First superfluous path elements like ../ or // are removed.
 	// Afterwards the router does a case-insensitive lookup of the cleaned path.
-	// If a handle can be found for this route, the router makes a redirection
-	// to the corrected path with status code 301 for GET requests and 307 for
-	// all other request methods.
-	// For example /FOO and /..//Foo could be redirected to /foo.
+	// If a handle can be found for this route, Serve returns a RouteError
+	// with ErrRedirect, and the corrected path as the FixedPath.
+	// For example /FOO and /..//Foo could be corrected to /foo.
 	// RedirectTrailingSlash is independent of this option.
 	RedirectFixedPath bool
 
-	// Configurable Handler which is called when no matching route is found.
-	NotFound actions.Action
+	// Configurable factory which is called when no matching route is found,
+	// it receives the original request, and should return a fresh action for each call.
+	// The global middlewares are applied to it too.
+	// If it's nil, Serve returns a RouteError with ErrNotFound instead.
+	NotFound func(req *Request) actions.Action
 }
 
 // New returns a new initialized Router.
@@ -77,40 +163,123 @@ func New() *Router {
 	}
 }
 
-// Handle registers a new request handle with the given path and method.
-//
-// For GET, POST, PUT, PATCH and DELETE requests the respective shortcut
-// functions can be used.
-//
-// This function is intended for bulk loading and to allow the usage of less
-// frequently used, non-standardized or custom methods (e.g. for internal
-// communication with a proxy).
-func (r *Router) Handle(actionType ActionType, path string, handle Handle) {
+// Use appends the middlewares for all the routes,
+// including the routes registered before
+func (r *Router) Use(mws ...Middleware) {
+	r.update(func(t *table) {
+		t.middlewares = append(t.middlewares, mws...)
+	})
+}
+
+// UseGroup appends the middlewares for the routes of the action type,
+// they are applied after the global ones
+func (r *Router) UseGroup(actionType ActionType, mws ...Middleware) {
+	r.update(func(t *table) {
+		group := string(actionType)
+		t.groups[group] = append(t.groups[group], mws...)
+	})
+}
+
+// Handle registers a new request handle with the given path and action type.
+// Options can be used for configuring the route, e.g. WithMiddleware
+func (r *Router) Handle(actionType ActionType, path string, handle Handle, opts ...RouteOption) {
 	if path[0] != '/' {
 		panic("path must begin with '/' in path '" + path + "'")
 	}
 
-	group := string(actionType)
+	path, schema := parseTypes(path)
 
-	if r.trees == nil {
-		r.trees = make(map[string]*node)
+	rt := &route{
+		group: string(actionType),
+		path:  path,
+	}
+	WithParams(schema)(rt)
+	for _, opt := range opts {
+		opt(rt)
 	}
 
-	root := r.trees[group]
-	if root == nil {
-		root = new(node)
-		r.trees[string(actionType)] = root
+	if len(rt.schema) > 0 {
+		handle = validate(rt.schema, handle)
+	}
+	if rt.deprecated != "" {
+		handle = deprecate(rt.deprecated, handle)
 	}
+	rt.handle = chain(handle, rt.middlewares)
 
-	root.addRoute(path, handle)
+	r.update(func(t *table) {
+		t.add(rt)
+	})
 }
 
-// Serve - get the Action by given path
-func (r *Router) Serve(actionType ActionType, path string) actions.Action {
-	group := string(actionType)
-	if root := r.trees[group]; root != nil {
+// Remove the route of the action type and the path,
+// it returns false if the route doesn't exist
+func (r *Router) Remove(actionType ActionType, path string) (removed bool) {
+	path, _ = parseTypes(path)
+
+	r.update(func(t *table) {
+		removed = t.remove(string(actionType), path)
+	})
+	return removed
+}
+
+// Reload rebuilds the whole route table by the build function, and swaps it atomically.
+// The build function registers the routes and the middlewares to a fresh router,
+// if it panics, e.g. conflicting routes, the error is returned and the current table is kept.
+func (r *Router) Reload(build func(b *Router)) (err error) {
+	b := &Router{}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("reload router: %v", v)
+		}
+	}()
+	build(b)
+
+	r.mu.Lock()
+	r.table.Store(b.load())
+	r.mu.Unlock()
+	return nil
+}
+
+// RouteConfig of a route, see Load
+type RouteConfig struct {
+	Type    ActionType
+	Path    string
+	Handle  Handle
+	Options []RouteOption
+}
+
+// Load rebuilds the routes from the configs, and swaps them atomically,
+// the middlewares of the router are kept
+func (r *Router) Load(configs []RouteConfig) error {
+	mws, groups := r.load().middlewares, r.load().groups
+
+	return r.Reload(func(b *Router) {
+		b.Use(mws...)
+		for group, gmws := range groups {
+			b.UseGroup(ActionType(group), gmws...)
+		}
+
+		for _, c := range configs {
+			b.Handle(c.Type, c.Path, c.Handle, c.Options...)
+		}
+	})
+}
+
+// Serve - get the Action by the type and the path of the request.
+// If no action can be served, a *RouteError is returned, which tells:
+// the route is unknown (ErrNotFound), the route exists under other action types (ErrWrongType),
+// or the route exists with a corrected path (ErrRedirect)
+func (r *Router) Serve(req *Request) (actions.Action, error) {
+	t := r.load()
+	path := req.Path
+	group := string(req.Type)
+
+	if root := t.trees[group]; root != nil {
 		if handle, ps, tsr := root.getValue(path); handle != nil {
-			return handle(ps)
+			handle = chain(handle, t.groups[group])
+			handle = chain(handle, t.middlewares)
+			return handle(req, ps)
 		} else if path != "/" {
 			if tsr && r.RedirectTrailingSlash {
 				if len(path) > 1 && path[len(path)-1] == '/' {
@@ -118,7 +287,7 @@ func (r *Router) Serve(actionType ActionType, path string) actions.Action {
 				} else {
 					path = path + "/"
 				}
-				return r.Serve(actionType, path)
+				return nil, &RouteError{Err: ErrRedirect, Type: req.Type, Path: req.Path, FixedPath: path}
 			}
 
 			// Try to fix the request path
@@ -128,16 +297,35 @@ func (r *Router) Serve(actionType ActionType, path string) actions.Action {
 					r.RedirectTrailingSlash,
 				)
 				if found {
-					path = string(fixedPath)
-					return r.Serve(actionType, path)
+					return nil, &RouteError{Err: ErrRedirect, Type: req.Type, Path: req.Path, FixedPath: string(fixedPath)}
 				}
 			}
 		}
 	}
 
+	// Check if the route exists under other action types
+	var allowed []ActionType
+	for other, root := range t.trees {
+		if other == group {
+			continue
+		}
+
+		if handle, _, _ := root.getValue(path); handle != nil {
+			allowed = append(allowed, ActionType(other))
+		}
+	}
+
+	if len(allowed) > 0 {
+		sort.Slice(allowed, func(i, j int) bool { return allowed[i] < allowed[j] })
+		return nil, &RouteError{Err: ErrWrongType, Type: req.Type, Path: req.Path, Allowed: allowed}
+	}
+
 	if r.NotFound != nil {
-		return r.NotFound
+		handle := chain(func(req *Request, _ Params) (actions.Action, error) {
+			return r.NotFound(req), nil
+		}, t.middlewares)
+		return handle(req, nil)
 	}
 
-	return nil
+	return nil, &RouteError{Err: ErrNotFound, Type: req.Type, Path: req.Path}
 }
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/sleep2death/hexcore/actions"
@@ -8,18 +9,125 @@ import (
 )
 
 func TestRouter(t *testing.T) {
+	served := 0
+
 	r := New()
-	r.Handle(Card, "/strike/:card_id/:target_id", func(ps Params) actions.Action {
+	r.Handle(Card, "/strike/:card_id/:target_id", func(req *Request, ps Params) (actions.Action, error) {
 		cid := ps.ByName("card_id")
 		tid := ps.ByName("target_id")
 		// t.Logf("card_id: %s, target_id: %s", ps.ByName("card_id"), ps.ByName("target_id"))
 		assert.Equal(t, "abc", cid)
 		assert.Equal(t, "def", tid)
-		return nil
+		served++
+		return nil, nil
 	})
 
-	r.Serve(Card, "strike/abc/def")
-	r.Serve(Card, "Strike/abc/def")
-	r.Serve(Card, "Strike/abc/def/")
-	r.Serve(Card, "strike/abc/def/")
+	_, err := r.Serve(&Request{Type: Card, Path: "/strike/abc/def"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, served)
+
+	// the paths are not rewritten silently, the corrected path is returned instead
+	for _, path := range []string{"strike/abc/def", "Strike/abc/def", "Strike/abc/def/", "strike/abc/def/", "/strike/abc/def/"} {
+		_, err := r.Serve(&Request{Type: Card, Path: path})
+		assert.Equal(t, &RouteError{Err: ErrRedirect, Type: Card, Path: path, FixedPath: "/strike/abc/def"}, err)
+	}
+	assert.Equal(t, 1, served)
+}
+
+type notFound struct {
+	path string
+}
+
+func (a *notFound) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	return nil, nil
+}
+
+func TestServeErrors(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/play/:id", nopHandle)
+	r.Handle(Battle, "/play/:id", nopHandle)
+	r.Handle(Battle, "/end", nopHandle)
+
+	_, err := r.Serve(&Request{Type: Normal, Path: "/play/a"})
+	assert.Equal(t, &RouteError{Err: ErrWrongType, Type: Normal, Path: "/play/a", Allowed: []ActionType{Battle, Card}}, err)
+	assert.Equal(t, "Normal /play/a: route exists under a different action type Battle, Card", err.Error())
+
+	_, err = r.Serve(&Request{Type: Card, Path: "/unknown"})
+	assert.Equal(t, &RouteError{Err: ErrNotFound, Type: Card, Path: "/unknown"}, err)
+	assert.Equal(t, "Card /unknown: route not found", err.Error())
+
+	_, err = r.Serve(&Request{Type: Battle, Path: "/END"})
+	assert.Equal(t, "Battle /END: route exists with a corrected path /end", err.Error())
+
+	// no redirection suggestion
+	r.RedirectFixedPath = false
+	r.RedirectTrailingSlash = false
+	_, err = r.Serve(&Request{Type: Battle, Path: "/end/"})
+	assert.Equal(t, ErrNotFound, err.(*RouteError).Err)
+
+	// fresh not found action for each call
+	r.NotFound = func(req *Request) actions.Action {
+		return &notFound{path: req.Path}
+	}
+
+	a, err := r.Serve(&Request{Type: Card, Path: "/a"})
+	assert.Equal(t, nil, err)
+	b, _ := r.Serve(&Request{Type: Card, Path: "/b"})
+	assert.Equal(t, &notFound{path: "/a"}, a)
+	assert.Equal(t, &notFound{path: "/b"}, b)
+}
+
+type playCard struct {
+	Session string
+	Player  string
+	Seq     uint64
+	ID      string
+	Target  string
+	Choices []int
+}
+
+func (a *playCard) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	return nil, nil
+}
+
+func TestRequest(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/card/play/:id", func(req *Request, ps Params) (actions.Action, error) {
+		action := &playCard{}
+		if err := req.Decode(action); err != nil {
+			return nil, err
+		}
+
+		action.Session = req.Session
+		action.Player = req.Player
+		action.Seq = req.Seq
+		action.ID = ps.ByName("id")
+		return action, nil
+	})
+
+	action, err := r.Serve(&Request{
+		Type:    Card,
+		Path:    "/card/play/c1",
+		Session: "s1",
+		Player:  "p1",
+		Seq:     7,
+		Payload: json.RawMessage(`{"target": "m1", "choices": [1, 2]}`),
+	})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &playCard{
+		Session: "s1",
+		Player:  "p1",
+		Seq:     7,
+		ID:      "c1",
+		Target:  "m1",
+		Choices: []int{1, 2},
+	}, action)
+
+	// handle error is returned
+	_, err = r.Serve(&Request{Type: Card, Path: "/card/play/c1"})
+	assert.Equal(t, ErrNoPayload, err)
+
+	_, err = r.Serve(&Request{Type: Card, Path: "/card/play/c1", Payload: json.RawMessage(`{`)})
+	assert.NotEqual(t, nil, err)
 }
@@ -0,0 +1,100 @@
+package router
+
+// table of the routes, it's immutable once it's stored in the router,
+// any change is made on a copy of it, see Router.update
+type table struct {
+	// routes in the registration order
+	routes []*route
+	trees  map[string]*node
+
+	// middlewares for all the routes
+	middlewares []Middleware
+	// middlewares for the routes of the action type
+	groups map[string][]Middleware
+}
+
+func newTable() *table {
+	return &table{
+		trees:  make(map[string]*node),
+		groups: make(map[string][]Middleware),
+	}
+}
+
+// load the current table of the router
+func (r *Router) load() *table {
+	if t, ok := r.table.Load().(*table); ok {
+		return t
+	}
+	return newTable()
+}
+
+// update makes a copy of the current table, changes it by fn, then swaps it.
+// If fn panics, the current table is kept.
+func (r *Router) update(fn func(t *table)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.load().copy()
+	fn(t)
+	r.table.Store(t)
+}
+
+// copy the table, the trees are shared,
+// since they are rebuilt rather than modified when the routes changed
+func (t *table) copy() *table {
+	c := newTable()
+	c.routes = append(c.routes, t.routes...)
+	c.middlewares = append(c.middlewares, t.middlewares...)
+
+	for group, root := range t.trees {
+		c.trees[group] = root
+	}
+	for group, mws := range t.groups {
+		c.groups[group] = append([]Middleware(nil), mws...)
+	}
+	return c
+}
+
+// add the route, and rebuild the tree of its group
+func (t *table) add(rt *route) {
+	t.routes = append(t.routes, rt)
+	t.build(rt.group)
+}
+
+// remove the route, and rebuild the tree of its group
+func (t *table) remove(group, path string) bool {
+	for i, rt := range t.routes {
+		if rt.group == group && rt.path == path {
+			t.routes = append(t.routes[:i:i], t.routes[i+1:]...)
+			t.build(group)
+			return true
+		}
+	}
+	return false
+}
+
+// build the tree of the group from the routes
+func (t *table) build(group string) {
+	root := new(node)
+	for _, rt := range t.routes {
+		if rt.group == group {
+			root.addRoute(rt.path, rt.handle)
+		}
+	}
+
+	if len(root.path) == 0 && len(root.children) == 0 {
+		delete(t.trees, group)
+		return
+	}
+	t.trees[group] = root
+}
+
+// lookup the route options
+func (t *table) lookup(group, path string) *route {
+	for _, rt := range t.routes {
+		if rt.group == group && rt.path == path {
+			return rt
+		}
+	}
+	return &route{}
+}
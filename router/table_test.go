@@ -0,0 +1,121 @@
+package router
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func valueHandle(v string) Handle {
+	return func(req *Request, ps Params) (actions.Action, error) {
+		return &actions.OutputString{Message: v}, nil
+	}
+}
+
+func serveValue(r *Router, actionType ActionType, path string) string {
+	action, err := r.Serve(&Request{Type: actionType, Path: path})
+	if err != nil {
+		return err.(*RouteError).Err.Error()
+	}
+	return action.(*actions.OutputString).Message
+}
+
+func TestRemove(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/play/:id<int>", valueHandle("play"))
+	r.Handle(Card, "/discard/:id", valueHandle("discard"))
+	r.Handle(Battle, "/end", valueHandle("end"))
+
+	assert.True(t, r.Remove(Card, "/play/:id<int>"))
+	assert.False(t, r.Remove(Card, "/play/:id"))
+	assert.False(t, r.Remove(Battle, "/discard/:id"))
+
+	assert.Equal(t, ErrNotFound.Error(), serveValue(r, Card, "/play/1"))
+	assert.Equal(t, "discard", serveValue(r, Card, "/discard/1"))
+
+	// the wildcard can be registered with another name now
+	r.Handle(Card, "/play/:card", valueHandle("play card"))
+	assert.Equal(t, "play card", serveValue(r, Card, "/play/1"))
+
+	assert.True(t, r.Remove(Battle, "/end"))
+	assert.Equal(t, ErrNotFound.Error(), serveValue(r, Battle, "/end"))
+	assert.Equal(t, 2, len(r.Routes()))
+}
+
+func TestReload(t *testing.T) {
+	var trace []string
+
+	r := New()
+	r.Use(record(&trace, "global"))
+	r.Handle(Card, "/play/:id", valueHandle("v1"))
+
+	// conflicting routes, the current table is kept
+	err := r.Reload(func(b *Router) {
+		b.Handle(Card, "/play/:id", valueHandle("v2"))
+		b.Handle(Card, "/play/:card", valueHandle("v2"))
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "v1", serveValue(r, Card, "/play/1"))
+
+	// the middlewares are kept by Load
+	err = r.Load([]RouteConfig{
+		{Type: Card, Path: "/play/:id", Handle: valueHandle("v2")},
+		{Type: Battle, Path: "/end", Handle: valueHandle("end")},
+	})
+	assert.Nil(t, err)
+
+	trace = nil
+	assert.Equal(t, "v2", serveValue(r, Card, "/play/1"))
+	assert.Equal(t, "end", serveValue(r, Battle, "/end"))
+	assert.Equal(t, []string{"global>", "<global", "global>", "<global"}, trace)
+
+	// the whole router is replaced by Reload
+	err = r.Reload(func(b *Router) {
+		b.Handle(Battle, "/end", valueHandle("end"))
+	})
+	assert.Nil(t, err)
+
+	trace = nil
+	assert.Equal(t, ErrNotFound.Error(), serveValue(r, Card, "/play/1"))
+	assert.Equal(t, "end", serveValue(r, Battle, "/end"))
+	assert.Equal(t, 0, len(trace))
+}
+
+func TestConcurrentRoutes(t *testing.T) {
+	r := New()
+	r.Handle(Card, "/play/:id", valueHandle("play"))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		// register and remove the card pack routes
+		go func(i int) {
+			defer wg.Done()
+			path := "/pack" + strconv.Itoa(i) + "/:id"
+			for j := 0; j < 20; j++ {
+				r.Handle(Card, path, valueHandle("pack"))
+				r.Remove(Card, path)
+			}
+		}(i)
+
+		// in-flight serves are never dropped
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				assert.Equal(t, "play", serveValue(r, Card, "/play/1"))
+				if j%5 == 0 {
+					r.Reload(func(b *Router) {
+						b.Handle(Card, "/play/:id", valueHandle("play"))
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "play", serveValue(r, Card, "/play/1"))
+}
@@ -318,6 +318,18 @@ func (n *node) insertChild(numParams uint8, path, fullPath string, handle Handle
 	n.handle = handle
 }
 
+// walk calls fn for every handle of the tree, with its full path
+func (n *node) walk(prefix string, fn func(path string, handle Handle)) {
+	prefix += n.path
+	if n.handle != nil {
+		fn(prefix, n.handle)
+	}
+
+	for _, child := range n.children {
+		child.walk(prefix, fn)
+	}
+}
+
 // Returns the handle registered with the given path (key). The values of
 // wildcards are saved to a map.
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
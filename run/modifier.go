@@ -0,0 +1,51 @@
+package run
+
+import "sync"
+
+// Modifier changes the named value of the run, e.g. the gold of the rewards,
+// it returns the value unchanged if it doesn't care about the name
+type Modifier func(r *Run, name string, value int) int
+
+type modifier struct {
+	id int
+	m  Modifier
+}
+
+var (
+	mu        sync.RWMutex
+	seq       int
+	modifiers []modifier
+)
+
+// Modify the values of all the runs, the modifiers are applied in the order they are added,
+// call the returned function to remove the modifier
+func Modify(m Modifier) (remove func()) {
+	mu.Lock()
+	seq++
+	id := seq
+	modifiers = append(modifiers, modifier{id: id, m: m})
+	mu.Unlock()
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, e := range modifiers {
+			if e.id == id {
+				modifiers = append(modifiers[:i:i], modifiers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Value returns the named value modified by all the modifiers
+func (r *Run) Value(name string, value int) int {
+	mu.RLock()
+	ms := modifiers
+	mu.RUnlock()
+
+	for _, e := range ms {
+		value = e.m(r, name, value)
+	}
+	return value
+}
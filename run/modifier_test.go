@@ -0,0 +1,32 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModify(t *testing.T) {
+	r := New("modify", 1)
+	assert.Equal(t, 10, r.Value("gold", 10))
+
+	double := Modify(func(r *Run, name string, value int) int {
+		if name == "gold" {
+			return value * 2
+		}
+		return value
+	})
+	plus := Modify(func(r *Run, name string, value int) int {
+		return value + r.Ascension
+	})
+
+	// applied in order
+	r.Ascension = 1
+	assert.Equal(t, 21, r.Value("gold", 10))
+	assert.Equal(t, 6, r.Value("hp", 5))
+
+	double()
+	assert.Equal(t, 11, r.Value("gold", 10))
+	plus()
+	assert.Equal(t, 10, r.Value("gold", 10))
+}
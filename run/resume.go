@@ -0,0 +1,73 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/sleep2death/hexcore/actions"
+)
+
+var (
+	// ErrUnknownPending -
+	ErrUnknownPending = errors.New("no resumer of the pending kind")
+)
+
+// Pending part of the run at the safe point, e.g. the room entered but not completed,
+// it's entered again when the saved run is resumed
+type Pending struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// NewPending of the kind with the data encoded, the data is optional
+func NewPending(kind string, data interface{}) (*Pending, error) {
+	p := &Pending{Kind: kind}
+	if data == nil {
+		return p, nil
+	}
+
+	var err error
+	p.Data, err = json.Marshal(data)
+	return p, err
+}
+
+// Resumer enters the pending part of the kind again
+type Resumer func(ctx *actions.Context, r *Run, p *Pending) ([]actions.Action, error)
+
+var (
+	resumersMu sync.RWMutex
+	resumers   = make(map[string]Resumer)
+)
+
+// OnResume registers the resumer of the pending kind, it replaces the previous one
+func OnResume(kind string, fn Resumer) {
+	resumersMu.Lock()
+	resumers[kind] = fn
+	resumersMu.Unlock()
+}
+
+// Resume action continues the run loaded from the save, after it's attached to the chain.
+// The pending part of the run is entered again, nothing is done if there is none.
+type Resume struct {
+}
+
+// Exec -
+func (a *Resume) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := Of(ctx)
+	if r == nil {
+		return nil, ErrNoRun
+	}
+	if r.Pending == nil {
+		return nil, nil
+	}
+
+	resumersMu.RLock()
+	fn, ok := resumers[r.Pending.Kind]
+	resumersMu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownPending
+	}
+	return fn(ctx, r, r.Pending)
+}
@@ -0,0 +1,52 @@
+package run
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResume(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	var resumed string
+	OnResume("run_test", func(ctx *actions.Context, r *Run, p *Pending) ([]actions.Action, error) {
+		resumed = string(p.Data)
+		return nil, nil
+	})
+
+	// the pending part is saved with the run
+	r := newTestRun()
+	saver := &Saver{Dir: dir, Key: []byte("secret")}
+	p, err := NewPending("run_test", map[string]int{"floor": 3})
+	assert.Nil(t, err)
+	_, err = (&SafePoint{Saver: saver, Run: r, Pending: p}).Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, p, r.Pending)
+
+	l, err := saver.Read("run")
+	assert.Nil(t, err)
+	assert.Equal(t, p, l.Pending)
+
+	ctx := actions.NewContext(nil, nil, store.GetStore().AddState(l.State))
+	_, err = (&Resume{}).Exec(ctx)
+	assert.Equal(t, ErrNoRun, err)
+	_, err = (&Attach{Run: l}).Exec(ctx)
+	assert.Nil(t, err)
+	_, err = (&Resume{}).Exec(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"floor":3}`, resumed)
+
+	// nothing to resume, or the kind is unknown
+	l.Pending = nil
+	_, err = (&Resume{}).Exec(ctx)
+	assert.Nil(t, err)
+	l.Pending = &Pending{Kind: "run_unknown"}
+	_, err = (&Resume{}).Exec(ctx)
+	assert.Equal(t, ErrUnknownPending, err)
+}
@@ -0,0 +1,112 @@
+package run
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/rng"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrStateMismatch -
+	ErrStateMismatch = errors.New("chain is not started with the state of the run")
+	// ErrNoRun -
+	ErrNoRun = errors.New("no run is attached to the chain")
+)
+
+// Position of the player on the map
+type Position struct {
+	Act   int    `json:"act"`
+	Floor int    `json:"floor"`
+	Node  string `json:"node,omitempty"`
+}
+
+// Run holds all the data of the player across the battles.
+// The status of the player, e.g. the master deck (store.Deck), HP, gold and relics,
+// is kept in the state, and the run adds the progression on the map.
+// It's only accessed by the execution chain, one action by one,
+// so it's not concurrency safe.
+type Run struct {
+	ID    string
+	State *store.State
+
+	Position Position
+	// Pending part of the run at the last safe point, nil if nothing is in progress
+	Pending *Pending
+	// Ascension level of the run, 0 for the normal difficulty
+	Ascension int
+
+	// Counters of the run, e.g. the pity counter of the card rewards,
+	// the missing counter is 0
+	Counters map[string]int
+
+	// Seed of the run, every random stream is derived from it
+	Seed    int64
+	streams map[string]*rng.Source
+}
+
+// New run with the seed
+func New(id string, seed int64) *Run {
+	return &Run{
+		ID:       id,
+		State:    &store.State{},
+		Position: Position{Act: 1},
+		Counters: make(map[string]int),
+		Seed:     seed,
+		streams:  make(map[string]*rng.Source),
+	}
+}
+
+// key of the run in the chain context
+type key struct{}
+
+// key of the saver in the chain context
+type saverKey struct{}
+
+// Attach action attaches the run to the chain, so the following actions can find it by Of.
+// The chain must be started with the state of the run.
+type Attach struct {
+	Run *Run
+	// Saver writes the run at the safe points of the chain, optional
+	Saver *Saver
+}
+
+// Exec -
+func (a *Attach) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	if store.GetStore().State(ctx.ID()) != a.Run.State {
+		return nil, ErrStateMismatch
+	}
+	ctx.SetValue(key{}, a.Run)
+	if a.Saver != nil {
+		ctx.SetValue(saverKey{}, a.Saver)
+	}
+	return nil, nil
+}
+
+// Of returns the run attached to the chain, nil if there isn't one
+func Of(ctx *actions.Context) *Run {
+	r, _ := ctx.Value(key{}).(*Run)
+	return r
+}
+
+// Rand returns the random generator of the named stream,
+// each stream is derived from the run seed independently,
+// so consuming one of them won't affect the others
+func (r *Run) Rand(stream string) *rand.Rand {
+	return rand.New(r.source(stream))
+}
+
+func (r *Run) source(stream string) *rng.Source {
+	if r.streams == nil {
+		r.streams = make(map[string]*rng.Source)
+	}
+
+	src, ok := r.streams[stream]
+	if !ok {
+		src = rng.NewSource(rng.Derive(r.Seed, stream))
+		r.streams[stream] = src
+	}
+	return src
+}
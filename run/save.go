@@ -0,0 +1,247 @@
+package run
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/rng"
+	"github.com/sleep2death/hexcore/store"
+)
+
+// SaveVersion is the current version of the run save,
+// increase it when the save is changed incompatibly
+const SaveVersion = 2
+
+var (
+	// ErrSaveVersion -
+	ErrSaveVersion = errors.New("unsupported save version")
+	// ErrChecksum -
+	ErrChecksum = errors.New("save checksum mismatch, it may be tampered")
+	// ErrNoState -
+	ErrNoState = errors.New("save without the state")
+	// ErrInvalidID -
+	ErrInvalidID = errors.New("invalid run id for the save")
+)
+
+// Save is the document of the run
+type Save struct {
+	Version   int                     `json:"version"`
+	ID        string                  `json:"id"`
+	State     *store.Snapshot         `json:"state"`
+	Position  Position                `json:"position"`
+	Pending   *Pending                `json:"pending,omitempty"`
+	Ascension int                     `json:"ascension,omitempty"`
+	Counters  map[string]int          `json:"counters,omitempty"`
+	Seed      int64                   `json:"seed"`
+	RNG       map[string]rng.Position `json:"rng,omitempty"`
+}
+
+// file is the layout of the save file,
+// data is kept raw, so the checksum can be verified with the exact bytes
+type file struct {
+	Checksum string          `json:"checksum"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Save the run into document
+func (r *Run) Save() *Save {
+	s := &Save{
+		Version:   SaveVersion,
+		ID:        r.ID,
+		State:     r.State.Snapshot(),
+		Position:  r.Position,
+		Pending:   r.Pending,
+		Ascension: r.Ascension,
+		Counters:  make(map[string]int, len(r.Counters)),
+		Seed:      r.Seed,
+		RNG:       make(map[string]rng.Position, len(r.streams)),
+	}
+
+	for name, v := range r.Counters {
+		s.Counters[name] = v
+	}
+
+	for name, src := range r.streams {
+		s.RNG[name] = src.Position()
+	}
+	return s
+}
+
+// Load the run from the document
+func Load(s *Save) (*Run, error) {
+	if s.Version != SaveVersion {
+		return nil, ErrSaveVersion
+	}
+
+	if s.State == nil {
+		return nil, ErrNoState
+	}
+
+	r := New(s.ID, s.Seed)
+	if err := r.State.Restore(s.State); err != nil {
+		return nil, err
+	}
+	r.Position = s.Position
+	r.Pending = s.Pending
+	r.Ascension = s.Ascension
+
+	for name, v := range s.Counters {
+		r.Counters[name] = v
+	}
+
+	for name, pos := range s.RNG {
+		r.streams[name] = pos.Source()
+	}
+	return r, nil
+}
+
+// Saver writes the run saves into the directory, one file per run.
+// Key is used for signing the saves, so the tampered saves will be rejected
+type Saver struct {
+	Dir string
+	Key []byte
+}
+
+// path of the save, the id must be a plain file name, so it can't escape the directory
+func (s *Saver) path(id string) (string, error) {
+	if id == "" || id == "." || strings.Contains(id, "..") || strings.ContainsAny(id, `/\`) {
+		return "", ErrInvalidID
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+func (s *Saver) checksum(data []byte) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Write the run save atomically,
+// the save is written into a temp file first, then renamed to the target
+func (s *Saver) Write(r *Run) error {
+	path, err := s.path(r.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(r.Save())
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(&file{
+		Checksum: s.checksum(data),
+		Data:     data,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(s.Dir, r.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read the run save, and validate it with the checksum
+func (s *Saver) Read(id string) (*Run, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{}
+	if err := json.Unmarshal(content, f); err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(f.Checksum), []byte(s.checksum(f.Data))) {
+		return nil, ErrChecksum
+	}
+
+	save := &Save{}
+	if err := json.Unmarshal(f.Data, save); err != nil {
+		return nil, err
+	}
+	return Load(save)
+}
+
+// Remove the run save, e.g. when the run is over
+func (s *Saver) Remove(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SafePoint action writes the run save,
+// add it into the chain where the run can be resumed safely,
+// e.g. entering a room or showing the reward screen.
+// The saver and the run attached to the chain are used if they are not set,
+// and nothing is written without a saver.
+type SafePoint struct {
+	Saver *Saver
+	Run   *Run
+	// Pending part of the run, which is entered again by Resume,
+	// it must be written before the part uses the random streams of the run
+	Pending *Pending
+}
+
+// Exec -
+func (a *SafePoint) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	saver, r := a.Saver, a.Run
+	if saver == nil {
+		saver, _ = ctx.Value(saverKey{}).(*Saver)
+	}
+	if r == nil {
+		r = Of(ctx)
+	}
+
+	if r != nil {
+		r.Pending = a.Pending
+	}
+
+	if saver == nil {
+		return nil, nil
+	}
+	if r == nil {
+		return nil, ErrNoRun
+	}
+	return nil, saver.Write(r)
+}
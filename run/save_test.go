@@ -0,0 +1,139 @@
+package run
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRun() *Run {
+	r := New("run", 42)
+	r.State.Update(func(tx *store.Tx) error {
+		for _, id := range []string{"a", "b", "c"} {
+			c := &cards.TestCard{}
+			c.SetID(id)
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), c)
+		}
+
+		tx.Player().SetID("player")
+		tx.Player().HP = 50
+		tx.Player().MaxHP = 80
+		tx.SetGold(99)
+		return tx.AddRelic("burning_blood")
+	})
+	r.Position = Position{Act: 1, Floor: 3, Node: "n3"}
+	r.Counters["pity"] = 2
+	r.Ascension = 3
+	return r
+}
+
+func TestSaveAndRead(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	saver := &Saver{Dir: dir, Key: []byte("secret")}
+
+	r := newTestRun()
+	r.Rand("cards").Intn(10)
+	_, err := (&SafePoint{Saver: saver, Run: r}).Exec(nil)
+	assert.Nil(t, err)
+
+	// only the save file is left, temp file is removed
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, "run.json", files[0].Name())
+
+	l, err := saver.Read("run")
+	assert.Nil(t, err)
+	assert.Equal(t, "[<card a> <card b> <card c>]", fmt.Sprint(l.State.GetPile(store.Deck)))
+	assert.Equal(t, uint(50), l.State.Player().HP)
+	assert.Equal(t, 99, l.State.Gold())
+	assert.Equal(t, []store.Relic{{ID: "burning_blood"}}, l.State.Relics())
+	assert.Equal(t, 2, l.Counters["pity"])
+	assert.Equal(t, 3, l.Ascension)
+	assert.Equal(t, r.Save(), l.Save())
+
+	// random streams continue from the saved position
+	assert.Equal(t, r.Rand("cards").Int(), l.Rand("cards").Int())
+	assert.Equal(t, r.Rand("map").Int(), l.Rand("map").Int())
+
+	assert.Nil(t, saver.Remove("run"))
+	assert.Nil(t, saver.Remove("run"))
+	_, err = saver.Read("run")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTamperedSave(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	saver := &Saver{Dir: dir, Key: []byte("secret")}
+	assert.Nil(t, saver.Write(newTestRun()))
+
+	path := filepath.Join(dir, "run.json")
+	content, _ := ioutil.ReadFile(path)
+	tampered := strings.Replace(string(content), `"gold":99`, `"gold":9999`, 1)
+	assert.NotEqual(t, string(content), tampered)
+	ioutil.WriteFile(path, []byte(tampered), 0600)
+
+	_, err := saver.Read("run")
+	assert.Equal(t, ErrChecksum, err)
+
+	// signed with another key
+	assert.Nil(t, saver.Write(newTestRun()))
+	_, err = (&Saver{Dir: dir, Key: []byte("other")}).Read("run")
+	assert.Equal(t, ErrChecksum, err)
+}
+
+func TestInvalidID(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	saver := &Saver{Dir: dir, Key: []byte("secret")}
+	for _, id := range []string{"", ".", "..", "../run", "a/b", `a\b`} {
+		r := newTestRun()
+		r.ID = id
+		assert.Equal(t, ErrInvalidID, saver.Write(r), id)
+
+		_, err := saver.Read(id)
+		assert.Equal(t, ErrInvalidID, err, id)
+		assert.Equal(t, ErrInvalidID, saver.Remove(id), id)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestAttachedSaver(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hexcore")
+	defer os.RemoveAll(dir)
+
+	r := newTestRun()
+	ctx := actions.NewContext(nil, nil, store.GetStore().AddState(r.State))
+
+	// nothing is written without a saver
+	_, err := (&Attach{Run: r}).Exec(ctx)
+	assert.Nil(t, err)
+	_, err = (&SafePoint{}).Exec(ctx)
+	assert.Nil(t, err)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 0, len(files))
+
+	saver := &Saver{Dir: dir, Key: []byte("secret")}
+	_, err = (&Attach{Run: r, Saver: saver}).Exec(ctx)
+	assert.Nil(t, err)
+	_, err = (&SafePoint{}).Exec(ctx)
+	assert.Nil(t, err)
+
+	l, err := saver.Read("run")
+	assert.Nil(t, err)
+	assert.Equal(t, r.Save(), l.Save())
+}
@@ -0,0 +1,368 @@
+package shop
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/classes"
+	"github.com/sleep2death/hexcore/hooks"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/potions"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+)
+
+var (
+	// ErrNotEnoughGold -
+	ErrNotEnoughGold = errors.New("not enough gold")
+	// ErrSoldOut -
+	ErrSoldOut = errors.New("item is sold out")
+)
+
+// Kind of the item
+type Kind string
+
+const (
+	// Card item is put into the deck
+	Card Kind = "card"
+	// Relic item
+	Relic Kind = "relic"
+	// Potion item is put into the empty potion slot
+	Potion Kind = "potion"
+)
+
+// Number of the items by the kind
+var Number = map[Kind]int{
+	Card:   5,
+	Relic:  3,
+	Potion: 3,
+}
+
+// Prices of the items by the kind and rarity,
+// the actual price is rolled within 10% of it
+var Prices = map[Kind]map[library.Rarity]int{
+	Card:   {library.Common: 50, library.Uncommon: 75, library.Rare: 150},
+	Relic:  {library.Common: 150, library.Uncommon: 250, library.Rare: 300},
+	Potion: {library.Common: 50, library.Uncommon: 75, library.Rare: 100},
+}
+
+// CardRarity weights of the cards on sale
+var CardRarity = map[library.Rarity]int{
+	library.Common:   54,
+	library.Uncommon: 37,
+	library.Rare:     9,
+}
+
+const (
+	// Sale is the discount (in percent) of the card on sale
+	Sale = 50
+	// RemovalPrice of the first card removal of the run
+	RemovalPrice = 75
+	// RemovalStep increases the removal price after every use
+	RemovalStep = 25
+)
+
+// removalCounter of the run
+const removalCounter = "card_removals"
+
+// Item of the shop
+type Item struct {
+	Kind  Kind   `json:"kind"`
+	ID    string `json:"id"`
+	Price int    `json:"price"`
+	Sale  bool   `json:"sale,omitempty"`
+	Sold  bool   `json:"sold,omitempty"`
+}
+
+// Shop is the inventory of the merchant
+type Shop struct {
+	Items []*Item `json:"items"`
+	// Removal price of the card removal service, 0 if it's used
+	Removal int `json:"removal"`
+}
+
+// Generate the inventory from the shop stream of the run
+func Generate(r *run.Run) (*Shop, error) {
+	rnd := r.Rand("shop")
+	s := &Shop{Removal: RemovalPrice + RemovalStep*r.Counters[removalCounter]}
+
+	var cards []string
+	for i := 0; i < Number[Card]; i++ {
+		rarity := library.Roll(rnd, CardRarity)
+		id := library.Pick(rnd, cardPool(r.State, rarity), cards)
+		if id == "" {
+			// every card of the rarity is on sale, try all of them
+			id = library.Pick(rnd, cardPool(r.State, ""), cards)
+		}
+		if id == "" {
+			break
+		}
+		cards = append(cards, id)
+		def, err := library.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		s.add(rnd, Card, id, def.Rarity)
+	}
+
+	if len(s.Items) > 0 {
+		item := s.Items[rnd.Intn(len(s.Items))]
+		item.Price = item.Price * (100 - Sale) / 100
+		item.Sale = true
+	}
+
+	var owned []string
+	for _, relic := range r.State.Relics() {
+		owned = append(owned, relic.ID)
+	}
+	for i := 0; i < Number[Relic]; i++ {
+		var ids []string
+		for _, def := range relics.Defs() {
+			if def.Rarity != library.Basic && def.Rarity != library.Special {
+				ids = append(ids, def.ID)
+			}
+		}
+		id := library.Pick(rnd, ids, owned)
+		if id == "" {
+			break
+		}
+		owned = append(owned, id)
+		def, err := relics.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		s.add(rnd, Relic, id, def.Rarity)
+	}
+
+	for i := 0; i < Number[Potion]; i++ {
+		id := potions.Random(rnd)
+		if id == "" {
+			break
+		}
+		def, err := potions.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		s.add(rnd, Potion, id, def.Rarity)
+	}
+	return s, nil
+}
+
+func (s *Shop) add(rnd *rand.Rand, kind Kind, id string, rarity library.Rarity) {
+	base, ok := Prices[kind][rarity]
+	if !ok {
+		base = Prices[kind][library.Common]
+	}
+	s.Items = append(s.Items, &Item{Kind: kind, ID: id, Price: base * (90 + rnd.Intn(21)) / 100})
+}
+
+// cardPool of the player class and the rarity, all the rarities but basic and special if it's empty
+func cardPool(state *store.State, rarity library.Rarity) []string {
+	pool := classes.Pool(state)
+	pool.Rarity = string(rarity)
+
+	var ids []string
+	for _, def := range library.PoolDefs(pool) {
+		ids = append(ids, def.ID)
+	}
+	return ids
+}
+
+// Enter action generates the shop, and shows it to the player
+type Enter struct {
+}
+
+// Exec -
+func (a *Enter) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	s, err := Generate(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return []actions.Action{
+		&hooks.Trigger{Event: hooks.Event{Hook: hooks.ShopEntry}},
+		&screen{shop: s},
+	}, nil
+}
+
+// screen action asks the player to buy the items, remove a card or leave
+type screen struct {
+	shop *Shop
+}
+
+// Exec -
+func (a *screen) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	state := store.GetStore().State(ctx.ID())
+	gold := state.Gold()
+
+	p := prompt.New("shop", "", a.choose)
+	p.Data = a.shop
+
+	for i, item := range a.shop.Items {
+		if item.Sold {
+			continue
+		}
+
+		id, text := "buy:"+strconv.Itoa(i), item.ID+" ("+strconv.Itoa(item.Price)+" gold)"
+		switch {
+		case item.Price > gold:
+			p.AddDisabled(id, text, ErrNotEnoughGold.Error())
+		case item.Kind == Potion && state.PotionSlotsFull():
+			p.AddDisabled(id, text, store.ErrPotionSlotsFull.Error())
+		default:
+			p.Add(id, text)
+		}
+	}
+
+	if price := a.shop.Removal; price > 0 {
+		text := "remove a card (" + strconv.Itoa(price) + " gold)"
+		switch {
+		case price > gold:
+			p.AddDisabled("remove", text, ErrNotEnoughGold.Error())
+		case len(state.GetPile(store.Deck)) == 0:
+			p.AddDisabled("remove", text, "no card to remove")
+		default:
+			p.Add("remove", text)
+		}
+	}
+
+	p.Add("leave", "leave the shop")
+	return []actions.Action{&prompt.Ask{Prompt: p}}, nil
+}
+
+func (a *screen) choose(ctx *actions.Context, option string) ([]actions.Action, error) {
+	switch {
+	case option == "leave":
+		return []actions.Action{&actions.Emit{Event: "shop_left"}}, nil
+	case option == "remove":
+		return []actions.Action{a.removal(ctx)}, nil
+	case strings.HasPrefix(option, "buy:"):
+		i, err := strconv.Atoi(strings.TrimPrefix(option, "buy:"))
+		if err != nil || i < 0 || i >= len(a.shop.Items) {
+			return nil, prompt.ErrUnknownOption
+		}
+		return []actions.Action{&Buy{Item: a.shop.Items[i]}, a}, nil
+	}
+	return nil, prompt.ErrUnknownOption
+}
+
+// removal asks the player to choose the card of the deck to remove
+func (a *screen) removal(ctx *actions.Context) actions.Action {
+	p := prompt.New("remove", "", func(ctx *actions.Context, option string) ([]actions.Action, error) {
+		if option == "cancel" {
+			return []actions.Action{a}, nil
+		}
+		return []actions.Action{&Remove{Shop: a.shop, Card: option}, a}, nil
+	})
+
+	for _, c := range store.GetStore().State(ctx.ID()).GetPile(store.Deck) {
+		p.Add(c.ID(), c.Type())
+	}
+	p.Add("cancel", "back to the shop")
+	return &prompt.Ask{Prompt: p}
+}
+
+// Buy action pays for the item, and puts it into the run inventory,
+// it's rejected if the gold is not enough or the item is sold.
+// The relic is obtained by relics.Obtain after it's paid.
+type Buy struct {
+	Item *Item
+}
+
+// Exec -
+func (a *Buy) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	item := a.Item
+	if item.Sold {
+		return actions.Reject("buy", ErrSoldOut), nil
+	}
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		if tx.Gold() < item.Price {
+			return ErrNotEnoughGold
+		}
+		tx.SetGold(tx.Gold() - item.Price)
+
+		switch item.Kind {
+		case Card:
+			card, err := library.New(item.ID)
+			if err != nil {
+				return err
+			}
+			*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), card)
+			return nil
+		case Relic:
+			if _, err := relics.Get(item.ID); err != nil {
+				return err
+			}
+			if _, err := tx.Relic(item.ID); err == nil {
+				return store.ErrRelicExists
+			}
+			return nil
+		case Potion:
+			_, err := tx.AddPotion(item.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return actions.Reject("buy", err), nil
+	}
+
+	item.Sold = true
+	next := []actions.Action{&actions.Emit{Event: "purchased", Data: item}}
+	if item.Kind == Relic {
+		next = append(next, &relics.Obtain{ID: item.ID})
+	}
+	return next, nil
+}
+
+// Remove action removes the card from the deck for the removal price of the shop,
+// the service can be used once per shop, and its price increases after every use
+type Remove struct {
+	Shop *Shop
+	Card string
+}
+
+// Exec -
+func (a *Remove) Exec(ctx *actions.Context) ([]actions.Action, error) {
+	r := run.Of(ctx)
+	if r == nil {
+		return nil, run.ErrNoRun
+	}
+
+	price := a.Shop.Removal
+	if price == 0 {
+		return actions.Reject("remove", ErrSoldOut), nil
+	}
+
+	err := store.GetStore().State(ctx.ID()).Update(func(tx *store.Tx) error {
+		if tx.Gold() < price {
+			return ErrNotEnoughGold
+		}
+
+		if err := library.Remove(tx, a.Card); err != nil {
+			return err
+		}
+		tx.SetGold(tx.Gold() - price)
+
+		// the service is used only after the card is removed
+		a.Shop.Removal = 0
+		r.Counters[removalCounter]++
+		return nil
+	})
+	if err != nil {
+		return actions.Reject("remove", err), nil
+	}
+
+	return []actions.Action{&actions.Emit{Event: "card_removed", Data: a.Card}}, nil
+}
@@ -0,0 +1,188 @@
+package shop
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actions"
+	"github.com/sleep2death/hexcore/effects"
+	"github.com/sleep2death/hexcore/library"
+	"github.com/sleep2death/hexcore/potions"
+	"github.com/sleep2death/hexcore/prompt"
+	"github.com/sleep2death/hexcore/relics"
+	"github.com/sleep2death/hexcore/run"
+	"github.com/sleep2death/hexcore/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	for id, rarity := range map[string]library.Rarity{
+		"shop_c1": library.Common, "shop_c2": library.Common, "shop_c3": library.Common,
+		"shop_u1": library.Uncommon, "shop_u2": library.Uncommon,
+		"shop_r1": library.Rare, "shop_strike": library.Basic,
+	} {
+		library.Define(&library.Def{ID: id, Name: id, Type: library.Skill, Rarity: rarity})
+	}
+
+	relics.Define(&relics.Def{ID: "shop_anchor", Rarity: library.Common})
+	relics.Define(&relics.Def{ID: "shop_lantern", Rarity: library.Rare})
+	relics.Define(&relics.Def{ID: "shop_starter", Rarity: library.Basic})
+
+	potions.Define(&potions.Def{ID: "shop_potion", Rarity: library.Common,
+		Effects: []effects.Effect{{Op: effects.Heal, Amount: 5}}})
+}
+
+func execute(ctx *actions.Context, action actions.Action) error {
+	next, err := action.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range next {
+		if err := execute(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func events(outc chan []byte) []string {
+	var evs []string
+	for {
+		select {
+		case data := <-outc:
+			e := &actions.Emit{}
+			json.Unmarshal(data, e)
+			evs = append(evs, e.Event)
+		default:
+			return evs
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, err := Generate(run.New("a", 5))
+	assert.Nil(t, err)
+	b, _ := Generate(run.New("b", 5))
+	assert.Equal(t, a, b)
+
+	s, err := Generate(run.New("shop", 9))
+	assert.Nil(t, err)
+	assert.Equal(t, RemovalPrice, s.Removal)
+
+	kinds := map[Kind]int{}
+	sales := 0
+	for _, item := range s.Items {
+		kinds[item.Kind]++
+		assert.NotEqual(t, "shop_strike", item.ID)
+		assert.NotEqual(t, "shop_starter", item.ID)
+
+		if item.Sale {
+			sales++
+			assert.Equal(t, Card, item.Kind)
+			continue
+		}
+
+		switch item.ID {
+		case "shop_c1", "shop_c2", "shop_c3", "shop_potion":
+			assert.True(t, item.Price >= 45 && item.Price <= 55, item.Price)
+		case "shop_lantern":
+			assert.True(t, item.Price >= 270 && item.Price <= 330, item.Price)
+		}
+	}
+	assert.Equal(t, 1, sales)
+	assert.Equal(t, map[Kind]int{Card: 5, Relic: 2, Potion: 3}, kinds)
+}
+
+func TestShop(t *testing.T) {
+	r := run.New("shop", 11)
+	outc := make(chan []byte, 64)
+	ctx := actions.NewContext(nil, outc, store.GetStore().AddState(r.State))
+	assert.Nil(t, execute(ctx, &run.Attach{Run: r}))
+
+	strike, _ := library.New("shop_strike")
+	r.State.Update(func(tx *store.Tx) error {
+		*tx.Pile(store.Deck) = append(*tx.Pile(store.Deck), strike)
+		return nil
+	})
+
+	// nothing can be bought without gold
+	assert.Nil(t, execute(ctx, &Enter{}))
+	assert.Equal(t, []string{"prompt"}, events(outc))
+	p := prompt.Pending(ctx)
+	s := p.Data.(*Shop)
+	for _, o := range p.Options {
+		if o.ID != "leave" {
+			assert.Equal(t, ErrNotEnoughGold.Error(), o.Disabled)
+		}
+	}
+
+	item := s.Items[0]
+	assert.Nil(t, execute(ctx, &Buy{Item: item}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	r.State.Update(func(tx *store.Tx) error {
+		tx.SetGold(500)
+		return nil
+	})
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "leave"}))
+	assert.Equal(t, []string{"shop_left"}, events(outc))
+
+	// buy the card
+	assert.Nil(t, execute(ctx, &Enter{}))
+	p = prompt.Pending(ctx)
+	s = p.Data.(*Shop)
+	item = s.Items[0]
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "buy:0"}))
+	assert.Equal(t, []string{"prompt", "purchased", "prompt"}, events(outc))
+	assert.Equal(t, 500-item.Price, r.State.Gold())
+	deck := r.State.GetPile(store.Deck)
+	assert.Len(t, deck, 2)
+	assert.Equal(t, item.ID, deck[1].Type())
+	assert.True(t, item.Sold)
+
+	_, ok := prompt.Pending(ctx).Option("buy:0")
+	assert.False(t, ok)
+	assert.Nil(t, execute(ctx, &Buy{Item: item}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+
+	// the failed removal keeps the service
+	gold := r.State.Gold()
+	assert.Nil(t, execute(ctx, &Remove{Shop: s, Card: "unknown"}))
+	assert.Equal(t, []string{"rejected"}, events(outc))
+	assert.Equal(t, gold, r.State.Gold())
+	assert.Equal(t, RemovalPrice, s.Removal)
+	assert.Equal(t, 0, r.Counters[removalCounter])
+
+	// remove the card, once per shop
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: "remove"}))
+	assert.Equal(t, "remove", prompt.Pending(ctx).Kind)
+	assert.Nil(t, execute(ctx, &prompt.Choose{Option: strike.ID()}))
+	assert.Equal(t, []string{"prompt", "card_removed", "prompt"}, events(outc))
+	assert.Equal(t, gold-RemovalPrice, r.State.Gold())
+	assert.Len(t, r.State.GetPile(store.Deck), 1)
+	assert.Equal(t, 0, s.Removal)
+	_, ok = prompt.Pending(ctx).Option("remove")
+	assert.False(t, ok)
+
+	// the relic is obtained after it's paid
+	bought := false
+	for i, item := range s.Items {
+		if item.Kind != Relic {
+			continue
+		}
+		gold := r.State.Gold()
+		assert.Nil(t, execute(ctx, &prompt.Choose{Option: "buy:" + strconv.Itoa(i)}))
+		assert.Equal(t, []string{"purchased", "relic_obtained", "prompt"}, events(outc))
+		assert.Equal(t, gold-item.Price, r.State.Gold())
+		assert.Equal(t, item.ID, r.State.Relics()[0].ID)
+		bought = true
+		break
+	}
+	assert.True(t, bought)
+
+	// the price increases in the next shop
+	next, err := Generate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, RemovalPrice+RemovalStep, next.Removal)
+}
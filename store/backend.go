@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrSessionNotFound -
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrNoBackend -
+	ErrNoBackend = errors.New("backend of the store is not set")
+)
+
+// Backend persists the encoded snapshots of the sessions,
+// it must be safe for concurrent use
+type Backend interface {
+	// Put the data of the session, overwrite the previous one
+	Put(session string, data []byte) error
+	// Get the data of the session, ErrSessionNotFound if not exist
+	Get(session string) ([]byte, error)
+	// List all the saved sessions, sorted
+	List() ([]string, error)
+	// Delete the session, it's not an error if the session doesn't exist
+	Delete(session string) error
+	// Close the backend
+	Close() error
+}
+
+// MemoryBackend keeps the sessions in memory, it's mainly for testing
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+// NewMemoryBackend -
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		sessions: make(map[string][]byte),
+	}
+}
+
+// Put -
+func (b *MemoryBackend) Put(session string, data []byte) error {
+	d := make([]byte, len(data))
+	copy(d, data)
+
+	b.mu.Lock()
+	b.sessions[session] = d
+	b.mu.Unlock()
+	return nil
+}
+
+// Get -
+func (b *MemoryBackend) Get(session string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	data, ok := b.sessions[session]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	d := make([]byte, len(data))
+	copy(d, data)
+	return d, nil
+}
+
+// List -
+func (b *MemoryBackend) List() ([]string, error) {
+	b.mu.RLock()
+	ids := make([]string, 0, len(b.sessions))
+	for id := range b.sessions {
+		ids = append(ids, id)
+	}
+	b.mu.RUnlock()
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete -
+func (b *MemoryBackend) Delete(session string) error {
+	b.mu.Lock()
+	delete(b.sessions, session)
+	b.mu.Unlock()
+	return nil
+}
+
+// Close -
+func (b *MemoryBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBackend(t *testing.T, b Backend) {
+	ids, err := b.List()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(ids))
+
+	assert.Nil(t, b.Put("b", []byte("hello")))
+	assert.Nil(t, b.Put("a", []byte("world")))
+	assert.Nil(t, b.Put("b", []byte("hello again")))
+
+	data, err := b.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello again", string(data))
+
+	_, err = b.Get("c")
+	assert.Equal(t, ErrSessionNotFound, err)
+
+	ids, _ = b.List()
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	assert.Nil(t, b.Delete("a"))
+	assert.Nil(t, b.Delete("c"))
+	ids, _ = b.List()
+	assert.Equal(t, []string{"b"}, ids)
+}
+
+func tempBolt(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "hexcore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "sessions.db"), func() { os.RemoveAll(dir) }
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemoryBackend())
+}
+
+func TestBoltBackend(t *testing.T) {
+	path, cleanup := tempBolt(t)
+	defer cleanup()
+
+	b, err := OpenBolt(path)
+	assert.Nil(t, err)
+	testBackend(t, b)
+	assert.Nil(t, b.Close())
+
+	// data is still there after reopened
+	b, err = OpenBolt(path)
+	assert.Nil(t, err)
+	defer b.Close()
+
+	data, err := b.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello again", string(data))
+}
+
+func TestCheckpoint(t *testing.T) {
+	path, cleanup := tempBolt(t)
+	defer cleanup()
+
+	st := &Store{}
+	_, err := st.Sessions()
+	assert.Equal(t, ErrNoBackend, err)
+
+	b, _ := OpenBolt(path)
+	st.SetBackend(b)
+
+	s := newSnapshotState()
+	idx := st.AddState(s)
+	assert.NotEqual(t, "", s.Session())
+	assert.Nil(t, st.Checkpoint(idx))
+	assert.Equal(t, ErrStateNotExist, st.Checkpoint(idx+1))
+	assert.Equal(t, ErrStateNotExist, st.Checkpoint(-1))
+
+	// the process restarted
+	b.Close()
+	b, _ = OpenBolt(path)
+	defer b.Close()
+
+	st = &Store{}
+	st.SetBackend(b)
+
+	ids, err := st.Sessions()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{s.Session()}, ids)
+
+	r, err := st.Load(s.Session())
+	assert.Nil(t, err)
+	assert.Equal(t, s.Session(), r.Session())
+	assert.Equal(t, s.Snapshot(), r.Snapshot())
+
+	// the session id is kept, when the state is added again
+	st.AddState(r)
+	assert.Equal(t, s.Session(), r.Session())
+
+	_, err = st.Load("unknown")
+	assert.Equal(t, ErrSessionNotFound, err)
+}
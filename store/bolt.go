@@ -0,0 +1,81 @@
+package store
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// BoltBackend persists the sessions into an embedded bbolt database file
+type BoltBackend struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (or creates) the database file of the path
+func OpenBolt(path string) (*BoltBackend, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltBackend{db: db}, nil
+}
+
+// Put -
+func (b *BoltBackend) Put(session string, data []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(session), data)
+	})
+}
+
+// Get -
+func (b *BoltBackend) Get(session string) (data []byte, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(sessionsBucket).Get([]byte(session))
+		if v == nil {
+			return ErrSessionNotFound
+		}
+
+		// the value is only valid inside the transaction
+		data = make([]byte, len(v))
+		copy(data, v)
+		return nil
+	})
+	return data, err
+}
+
+// List -
+func (b *BoltBackend) List() (ids []string, err error) {
+	ids = make([]string, 0)
+	err = b.db.View(func(tx *bolt.Tx) error {
+		// keys are sorted by bbolt already
+		return tx.Bucket(sessionsBucket).ForEach(func(k, _ []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// Delete -
+func (b *BoltBackend) Delete(session string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(session))
+	})
+}
+
+// Close the database
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
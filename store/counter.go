@@ -0,0 +1,151 @@
+package store
+
+import "errors"
+
+var (
+	// ErrCounterType -
+	ErrCounterType = errors.New("counter is of another type")
+)
+
+// CounterType of the counter
+type CounterType string
+
+const (
+	// Value counter holds a number, e.g. mantra, focus or charges
+	Value CounterType = "value"
+	// Slots counter holds the items in the limited slots, e.g. the orbs channeled or the stance
+	Slots CounterType = "slots"
+)
+
+// Counter of the player resources in the battle, they're reset when the battle starts or ends
+type Counter struct {
+	Type  CounterType `json:"type"`
+	Value int         `json:"value,omitempty"`
+	// Items in the slots, the first one is the oldest
+	Items []string `json:"items,omitempty"`
+	// Size is the number of the slots
+	Size int `json:"size,omitempty"`
+}
+
+// Count of the counter, the number of the items for the slots counter
+func (c *Counter) Count() int {
+	if c.Type == Slots {
+		return len(c.Items)
+	}
+	return c.Value
+}
+
+// Push the item into the slots, the oldest items are evicted if the slots are full,
+// e.g. the orbs are evoked. The item itself is evicted if there is no slot.
+func (c *Counter) Push(item string) (evicted []string) {
+	c.Items = append(c.Items, item)
+	return c.Resize(c.Size)
+}
+
+// Pop the oldest item, false if the slots are empty
+func (c *Counter) Pop() (string, bool) {
+	if len(c.Items) == 0 {
+		return "", false
+	}
+	item := c.Items[0]
+	c.Items = c.Items[1:]
+	return item, true
+}
+
+// Resize the slots, the oldest items are evicted if they exceed the size
+func (c *Counter) Resize(n int) (evicted []string) {
+	if n < 0 {
+		n = 0
+	}
+	c.Size = n
+	for len(c.Items) > n {
+		item, _ := c.Pop()
+		evicted = append(evicted, item)
+	}
+	return evicted
+}
+
+// DefaultSlots is the number of the slots of a new slots counter, unless it's resized
+const DefaultSlots = 1
+
+// Counter of the transaction by name, it's created if it doesn't exist,
+// and it can be modified directly
+func (tx *Tx) Counter(name string, typ CounterType) (*Counter, error) {
+	if c, ok := tx.counters[name]; ok {
+		if c.Type != typ {
+			return nil, ErrCounterType
+		}
+		return c, nil
+	}
+
+	if tx.counters == nil {
+		tx.counters = make(map[string]*Counter)
+	}
+	c := &Counter{Type: typ}
+	if typ == Slots {
+		c.Size = DefaultSlots
+	}
+	tx.counters[name] = c
+	return c, nil
+}
+
+// Count of the counter, 0 if it doesn't exist
+func (tx *Tx) Count(name string) int {
+	if c, ok := tx.counters[name]; ok {
+		return c.Count()
+	}
+	return 0
+}
+
+// ResetCounters removes all the counters
+func (tx *Tx) ResetCounters() {
+	tx.counters = nil
+}
+
+// Counter returns a copy of the counter, false if it doesn't exist
+func (s *State) Counter(name string) (Counter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.counters[name]
+	if !ok {
+		return Counter{}, false
+	}
+	return cloneCounter(c), true
+}
+
+// Counters returns a copy of all the counters
+func (s *State) Counters() map[string]Counter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.counters == nil {
+		return nil
+	}
+	cs := make(map[string]Counter, len(s.counters))
+	for name, c := range s.counters {
+		cs[name] = cloneCounter(c)
+	}
+	return cs
+}
+
+func cloneCounter(c *Counter) Counter {
+	cp := *c
+	if c.Items != nil {
+		cp.Items = make([]string, len(c.Items))
+		copy(cp.Items, c.Items)
+	}
+	return cp
+}
+
+func cloneCounters(cs map[string]*Counter) map[string]*Counter {
+	if cs == nil {
+		return nil
+	}
+	m := make(map[string]*Counter, len(cs))
+	for name, c := range cs {
+		cp := cloneCounter(c)
+		m[name] = &cp
+	}
+	return m
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounters(t *testing.T) {
+	s := &State{}
+	assert.Nil(t, s.Counters())
+	_, ok := s.Counter("mantra")
+	assert.False(t, ok)
+
+	s.Update(func(tx *Tx) error {
+		c, err := tx.Counter("mantra", Value)
+		assert.Nil(t, err)
+		c.Value += 4
+
+		_, err = tx.Counter("mantra", Slots)
+		assert.Equal(t, ErrCounterType, err)
+
+		orbs, _ := tx.Counter("orbs", Slots)
+		assert.Equal(t, DefaultSlots, orbs.Size)
+		assert.Nil(t, orbs.Resize(3))
+		for _, id := range []string{"lightning", "frost", "dark"} {
+			assert.Nil(t, orbs.Push(id))
+		}
+
+		// the oldest one is evicted
+		assert.Equal(t, []string{"lightning"}, orbs.Push("plasma"))
+		assert.Equal(t, []string{"frost", "dark"}, orbs.Resize(1))
+		assert.Equal(t, 1, tx.Count("orbs"))
+		assert.Equal(t, 4, tx.Count("mantra"))
+		assert.Equal(t, 0, tx.Count("charges"))
+		return nil
+	})
+
+	c, ok := s.Counter("orbs")
+	assert.True(t, ok)
+	assert.Equal(t, Counter{Type: Slots, Items: []string{"plasma"}, Size: 1}, c)
+
+	// the copies can't change the state
+	c.Items[0] = "x"
+	assert.Equal(t, []string{"plasma"}, s.Counters()["orbs"].Items)
+
+	// no slot, the item is evicted at once
+	s.Update(func(tx *Tx) error {
+		c, _ := tx.Counter("orbs", Slots)
+		assert.Equal(t, []string{"plasma"}, c.Resize(0))
+		assert.Equal(t, []string{"frost"}, c.Push("frost"))
+		item, ok := c.Pop()
+		assert.Equal(t, "", item)
+		assert.False(t, ok)
+		return nil
+	})
+
+	// rolled back
+	s.Update(func(tx *Tx) error {
+		c, _ := tx.Counter("mantra", Value)
+		c.Value = 10
+		return errors.New("rollback")
+	})
+	c, _ = s.Counter("mantra")
+	assert.Equal(t, 4, c.Value)
+
+	s.Update(func(tx *Tx) error {
+		tx.ResetCounters()
+		return nil
+	})
+	assert.Nil(t, s.Counters())
+}
@@ -0,0 +1,87 @@
+package store
+
+import "errors"
+
+var (
+	// ErrPotionSlotsFull -
+	ErrPotionSlotsFull = errors.New("potion slots are full")
+	// ErrPotionSlot -
+	ErrPotionSlot = errors.New("invalid potion slot")
+	// ErrPotionSlotEmpty -
+	ErrPotionSlotEmpty = errors.New("potion slot is empty")
+)
+
+// DefaultPotionSlots is the number of the potion slots, unless it's set by SetPotionSlots
+const DefaultPotionSlots = 3
+
+// Potions of the transaction, one potion id per slot, empty if the slot is empty
+func (tx *Tx) Potions() []string {
+	if tx.potions == nil {
+		tx.potions = make([]string, DefaultPotionSlots)
+	}
+	return tx.potions
+}
+
+// SetPotionSlots changes the number of the potion slots, down to 0,
+// the potions in the removed slots are dropped
+func (tx *Tx) SetPotionSlots(n int) {
+	if n < 0 {
+		n = 0
+	}
+	ps := make([]string, n)
+	copy(ps, tx.Potions())
+	tx.potions = ps
+}
+
+// AddPotion into the first empty slot, returns the slot
+func (tx *Tx) AddPotion(id string) (int, error) {
+	for i, p := range tx.Potions() {
+		if p == "" {
+			tx.potions[i] = id
+			return i, nil
+		}
+	}
+	return -1, ErrPotionSlotsFull
+}
+
+// RemovePotion from the slot, returns the removed potion id
+func (tx *Tx) RemovePotion(slot int) (string, error) {
+	ps := tx.Potions()
+	if slot < 0 || slot >= len(ps) {
+		return "", ErrPotionSlot
+	}
+
+	id := ps[slot]
+	if id == "" {
+		return "", ErrPotionSlotEmpty
+	}
+
+	ps[slot] = ""
+	return id, nil
+}
+
+// PotionSlotsFull returns true if there is no empty potion slot,
+// the slots are empty if they are never used
+func (s *State) PotionSlotsFull() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.potions == nil {
+		return DefaultPotionSlots == 0
+	}
+	for _, p := range s.potions {
+		if p == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func clonePotions(ps []string) []string {
+	if ps == nil {
+		return nil
+	}
+	c := make([]string, len(ps))
+	copy(c, ps)
+	return c
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPotions(t *testing.T) {
+	s := &State{}
+	assert.Nil(t, s.Potions())
+	assert.False(t, s.PotionSlotsFull())
+
+	s.Update(func(tx *Tx) error {
+		for i, id := range []string{"fire", "block", "swift"} {
+			slot, err := tx.AddPotion(id)
+			assert.Nil(t, err)
+			assert.Equal(t, i, slot)
+		}
+		_, err := tx.AddPotion("fairy")
+		assert.Equal(t, ErrPotionSlotsFull, err)
+		return nil
+	})
+	assert.Equal(t, []string{"fire", "block", "swift"}, s.Potions())
+	assert.True(t, s.PotionSlotsFull())
+
+	s.Update(func(tx *Tx) error {
+		id, err := tx.RemovePotion(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "block", id)
+
+		_, err = tx.RemovePotion(1)
+		assert.Equal(t, ErrPotionSlotEmpty, err)
+		_, err = tx.RemovePotion(3)
+		assert.Equal(t, ErrPotionSlot, err)
+
+		// the empty slot is filled first
+		slot, _ := tx.AddPotion("fairy")
+		assert.Equal(t, 1, slot)
+		return nil
+	})
+	assert.Equal(t, []string{"fire", "fairy", "swift"}, s.Potions())
+
+	// fewer slots drop the potions
+	s.Update(func(tx *Tx) error {
+		tx.SetPotionSlots(2)
+		return nil
+	})
+	assert.Equal(t, []string{"fire", "fairy"}, s.Potions())
+
+	s.Update(func(tx *Tx) error {
+		tx.SetPotionSlots(4)
+		return nil
+	})
+	assert.Equal(t, []string{"fire", "fairy", "", ""}, s.Potions())
+	assert.False(t, s.PotionSlotsFull())
+
+	// no slot is left with the negative number
+	s.Update(func(tx *Tx) error {
+		tx.SetPotionSlots(-1)
+		return nil
+	})
+	assert.Equal(t, []string{}, s.Potions())
+	assert.True(t, s.PotionSlotsFull())
+}
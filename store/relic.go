@@ -0,0 +1,90 @@
+package store
+
+import "errors"
+
+var (
+	// ErrRelicNotExist -
+	ErrRelicNotExist = errors.New("relic doesn't exist")
+	// ErrRelicExists -
+	ErrRelicExists = errors.New("relic is already owned")
+)
+
+// Relic owned by the player, the definition is looked up by its id,
+// and the counters are kept for the relics like "every 3rd attack",
+// one for each trigger of the relic, indexed by the trigger number
+type Relic struct {
+	ID       string      `json:"id"`
+	Counters map[int]int `json:"counters,omitempty"`
+	// Uses of the relic outside of its triggers, e.g. the lifts of Girya
+	Uses int `json:"uses,omitempty"`
+}
+
+// Count of the trigger
+func (r *Relic) Count(trigger int) int {
+	return r.Counters[trigger]
+}
+
+// SetCount of the trigger, the counter is dropped when it's reset to 0
+func (r *Relic) SetCount(trigger, n int) {
+	if n == 0 {
+		delete(r.Counters, trigger)
+		if len(r.Counters) == 0 {
+			r.Counters = nil
+		}
+		return
+	}
+
+	if r.Counters == nil {
+		r.Counters = make(map[int]int)
+	}
+	r.Counters[trigger] = n
+}
+
+// Relics of the transaction, the elements can be modified directly
+func (tx *Tx) Relics() []Relic {
+	return tx.relics
+}
+
+// Relic by id, it can be modified directly
+func (tx *Tx) Relic(id string) (*Relic, error) {
+	for i := range tx.relics {
+		if tx.relics[i].ID == id {
+			return &tx.relics[i], nil
+		}
+	}
+	return nil, ErrRelicNotExist
+}
+
+// AddRelic to the player, every relic can only be owned once
+func (tx *Tx) AddRelic(id string) error {
+	if _, err := tx.Relic(id); err == nil {
+		return ErrRelicExists
+	}
+	tx.relics = append(tx.relics, Relic{ID: id})
+	return nil
+}
+
+// RemoveRelic from the player
+func (tx *Tx) RemoveRelic(id string) error {
+	for i := range tx.relics {
+		if tx.relics[i].ID == id {
+			tx.relics = append(tx.relics[:i:i], tx.relics[i+1:]...)
+			return nil
+		}
+	}
+	return ErrRelicNotExist
+}
+
+func cloneRelics(rs []Relic) []Relic {
+	if rs == nil {
+		return nil
+	}
+	c := make([]Relic, len(rs))
+	for i, r := range rs {
+		c[i] = Relic{ID: r.ID, Uses: r.Uses}
+		for t, n := range r.Counters {
+			c[i].SetCount(t, n)
+		}
+	}
+	return c
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelics(t *testing.T) {
+	s := &State{}
+	assert.Nil(t, s.Relics())
+
+	err := s.Update(func(tx *Tx) error {
+		assert.Nil(t, tx.AddRelic("anchor"))
+		assert.Nil(t, tx.AddRelic("pen_nib"))
+		assert.Equal(t, ErrRelicExists, tx.AddRelic("anchor"))
+
+		r, err := tx.Relic("pen_nib")
+		assert.Nil(t, err)
+		r.SetCount(0, 9)
+		r.SetCount(1, 2)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Relic{{ID: "anchor"}, {ID: "pen_nib", Counters: map[int]int{0: 9, 1: 2}}}, s.Relics())
+
+	// the uses are kept apart from the counters
+	s.Update(func(tx *Tx) error {
+		r, _ := tx.Relic("anchor")
+		r.Uses = 3
+		return nil
+	})
+	assert.Equal(t, 3, s.Relics()[0].Uses)
+	s.Relics()[0].Uses = 0
+	assert.Equal(t, 3, s.Relics()[0].Uses)
+	s.Update(func(tx *Tx) error {
+		r, _ := tx.Relic("anchor")
+		r.Uses = 0
+		return nil
+	})
+
+	// the returned relics are copies
+	s.Relics()[1].SetCount(0, 0)
+	assert.Equal(t, 9, s.Relics()[1].Count(0))
+
+	// counters are rolled back with the transaction
+	s.Update(func(tx *Tx) error {
+		r, _ := tx.Relic("pen_nib")
+		r.SetCount(0, 0)
+		r.SetCount(1, 0)
+		assert.Nil(t, r.Counters)
+		assert.Nil(t, tx.RemoveRelic("anchor"))
+		return errors.New("rollback")
+	})
+	assert.Equal(t, []Relic{{ID: "anchor"}, {ID: "pen_nib", Counters: map[int]int{0: 9, 1: 2}}}, s.Relics())
+
+	s.Update(func(tx *Tx) error {
+		assert.Nil(t, tx.RemoveRelic("anchor"))
+		assert.Equal(t, ErrRelicNotExist, tx.RemoveRelic("anchor"))
+		_, err := tx.Relic("anchor")
+		assert.Equal(t, ErrRelicNotExist, err)
+		return nil
+	})
+	assert.Equal(t, []Relic{{ID: "pen_nib", Counters: map[int]int{0: 9, 1: 2}}}, s.Relics())
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/rng"
+)
+
+// SnapshotVersion is the current version of the snapshot document,
+// increase it when the document is changed incompatibly
+const SnapshotVersion = 3
+
+var (
+	// ErrSnapshotVersion -
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	// ErrSnapshotFormat -
+	ErrSnapshotFormat = errors.New("unknown snapshot format")
+)
+
+// Format of the encoded snapshot
+type Format int
+
+const (
+	// JSON format, human readable
+	JSON Format = iota
+	// Gob format, binary and compact
+	Gob
+)
+
+// Snapshot is a self-describing document of the whole state,
+// which can be encoded and restored later.
+// The number of the potion slots is kept apart from the potions,
+// so the state without any slot is restored as it is.
+type Snapshot struct {
+	Version     int                     `json:"version"`
+	Num         int                     `json:"num"`
+	Piles       map[string][]cards.Data `json:"piles"`
+	Player      actors.Data             `json:"player"`
+	Monsters    []actors.Data           `json:"monsters,omitempty"`
+	Energy      int                     `json:"energy"`
+	Gold        int                     `json:"gold"`
+	Relics      []Relic                 `json:"relics,omitempty"`
+	Potions     []string                `json:"potions,omitempty"`
+	PotionSlots int                     `json:"potion_slots"`
+	Counters    map[string]Counter      `json:"counters,omitempty"`
+	Seq         int                     `json:"seq"`
+	RNG         rng.Position            `json:"rng"`
+}
+
+// Snapshot of the state
+func (s *State) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := &Snapshot{
+		Version: SnapshotVersion,
+		Num:     s.num,
+		Piles:   make(map[string][]cards.Data),
+		Player:  s.player.Marshal(),
+		Energy:  s.energy,
+		Gold:    s.gold,
+		Relics:  cloneRelics(s.relics),
+		Seq:     s.seq,
+		RNG:     s.source().Position(),
+	}
+
+	// the empty slots at the end are restored by the number of the slots
+	snap.PotionSlots = DefaultPotionSlots
+	if s.potions != nil {
+		snap.PotionSlots = len(s.potions)
+		n := len(s.potions)
+		for n > 0 && s.potions[n-1] == "" {
+			n--
+		}
+		if n > 0 {
+			snap.Potions = clonePotions(s.potions[:n])
+		}
+	}
+
+	if s.counters != nil {
+		snap.Counters = make(map[string]Counter, len(s.counters))
+		for name, c := range s.counters {
+			snap.Counters[name] = cloneCounter(c)
+		}
+	}
+
+	for i, p := range s.piles {
+		if p != nil {
+			snap.Piles[PileName(i).String()] = p.Encode()
+		}
+	}
+
+	for _, m := range s.monsters {
+		snap.Monsters = append(snap.Monsters, m.Marshal())
+	}
+
+	return snap
+}
+
+// Restore the state from the snapshot,
+// the state won't be changed if any error returned
+func (s *State) Restore(snap *Snapshot) error {
+	if snap.Version != SnapshotVersion {
+		return ErrSnapshotVersion
+	}
+
+	var piles [pileCount]cards.Pile
+	for name, ds := range snap.Piles {
+		n, err := ParsePileName(name)
+		if err != nil {
+			return err
+		}
+
+		if piles[n], err = cards.DecodePile(ds); err != nil {
+			return err
+		}
+	}
+
+	var player actors.Player
+	player.Unmarshal(snap.Player)
+
+	var monsters []actors.Monster
+	for _, d := range snap.Monsters {
+		var m actors.Monster
+		m.Unmarshal(d)
+		monsters = append(monsters, m)
+	}
+
+	var counters map[string]*Counter
+	if snap.Counters != nil {
+		counters = make(map[string]*Counter, len(snap.Counters))
+		for name, c := range snap.Counters {
+			cp := cloneCounter(&c)
+			counters[name] = &cp
+		}
+	}
+
+	if snap.PotionSlots < len(snap.Potions) {
+		return ErrPotionSlot
+	}
+	potions := make([]string, snap.PotionSlots)
+	copy(potions, snap.Potions)
+
+	src := snap.RNG.Source()
+
+	s.mu.Lock()
+	s.num = snap.Num
+	s.piles = piles
+	s.player = player
+	s.monsters = monsters
+	s.energy = snap.Energy
+	s.gold = snap.Gold
+	s.relics = cloneRelics(snap.Relics)
+	s.potions = potions
+	s.counters = counters
+	s.seq = snap.Seq
+	s.rng = src
+	s.undo = nil
+	s.revealed = false
+	s.sealed = false
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Encode the snapshot with the given format
+func (snap *Snapshot) Encode(format Format) ([]byte, error) {
+	switch format {
+	case JSON:
+		return json.Marshal(snap)
+	case Gob:
+		buf := &bytes.Buffer{}
+		if err := gob.NewEncoder(buf).Encode(snap); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, ErrSnapshotFormat
+}
+
+// DecodeSnapshot from the data with the given format
+func DecodeSnapshot(data []byte, format Format) (*Snapshot, error) {
+	snap := &Snapshot{}
+
+	switch format {
+	case JSON:
+		if err := json.Unmarshal(data, snap); err != nil {
+			return nil, err
+		}
+	case Gob:
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(snap); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrSnapshotFormat
+	}
+
+	if snap.Version != SnapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	return snap, nil
+}
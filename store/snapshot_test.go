@@ -0,0 +1,177 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+type upgradableCard struct {
+	cards.Base
+}
+
+func (c *upgradableCard) Type() string {
+	return "upgradableCard"
+}
+
+func (c *upgradableCard) String() string {
+	return fmt.Sprintf("<%s+%d>", c.ID(), c.Upgrades())
+}
+
+func (c *upgradableCard) Copy() cards.Card {
+	return &upgradableCard{Base: c.CopyBase("copy of " + c.ID())}
+}
+
+func init() {
+	cards.RegisterType("upgradableCard", func() cards.Card { return &upgradableCard{} })
+}
+
+func newSnapshotState() *State {
+	s := &State{}
+	s.Seed(42)
+
+	s.Update(func(tx *Tx) error {
+		c := &upgradableCard{}
+		c.SetID("u")
+		c.Upgrade()
+		c.SetModifier("damage", 3)
+
+		tx.SetPile(Draw, append(newTestPile(5), c))
+		tx.SetPile(Hand, cards.Pile{})
+		tx.SetNum(7)
+		tx.SetEnergy(3)
+		tx.SetGold(99)
+		tx.AddRelic("akabeko")
+		tx.Relics()[0].SetCount(1, 2)
+		tx.AddPotion("fire_potion")
+
+		tx.Player().SetID("player")
+		tx.Player().HP = 70
+		tx.Player().MaxHP = 80
+		tx.Player().Class = "ironclad"
+		tx.Player().Energy = 4
+
+		orbs, _ := tx.Counter("orbs", Slots)
+		orbs.Resize(3)
+		orbs.Push("lightning")
+		mantra, _ := tx.Counter("mantra", Value)
+		mantra.Value = 7
+
+		m := actors.Monster{}
+		m.SetID("slime")
+		m.HP = 12
+		tx.SetMonsters([]actors.Monster{m})
+
+		tx.Shuffle(Draw)
+		return nil
+	})
+
+	return s
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, Gob} {
+		s := newSnapshotState()
+
+		data, err := s.Snapshot().Encode(format)
+		assert.Nil(t, err)
+
+		snap, err := DecodeSnapshot(data, format)
+		assert.Nil(t, err)
+
+		r := &State{}
+		assert.Nil(t, r.Restore(snap))
+
+		assert.Equal(t, 7, r.Num())
+		assert.Equal(t, 3, r.Energy())
+		assert.Equal(t, 99, r.Gold())
+		assert.Equal(t, []Relic{{ID: "akabeko", Counters: map[int]int{1: 2}}}, r.Relics())
+		assert.Equal(t, []string{"fire_potion", "", ""}, r.Potions())
+		assert.Equal(t, fmt.Sprint(s.GetPile(Draw)), fmt.Sprint(r.GetPile(Draw)))
+		assert.Equal(t, 0, len(r.GetPile(Hand)))
+		assert.Nil(t, r.GetPile(Discard))
+
+		p := r.Player()
+		assert.Equal(t, "player", p.ID())
+		assert.Equal(t, uint(70), p.HP)
+		assert.Equal(t, uint(80), p.MaxHP)
+		assert.Equal(t, "ironclad", p.Class)
+		assert.Equal(t, 4, p.Energy)
+
+		assert.Equal(t, map[string]Counter{
+			"orbs":   {Type: Slots, Items: []string{"lightning"}, Size: 3},
+			"mantra": {Type: Value, Value: 7},
+		}, r.Counters())
+
+		ms := r.Monsters()
+		assert.Equal(t, 1, len(ms))
+		assert.Equal(t, "slime", ms[0].ID())
+
+		draw := r.GetPile(Draw)
+		card, _, err := draw.FindCard("u")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, card.(*upgradableCard).Upgrades())
+		assert.Equal(t, 3, card.(*upgradableCard).Modifier("damage"))
+
+		// the random sequence continues from the same position
+		s.Shuffle(Draw)
+		r.Shuffle(Draw)
+		assert.Equal(t, fmt.Sprint(s.GetPile(Draw)), fmt.Sprint(r.GetPile(Draw)))
+	}
+}
+
+func TestSnapshotPotionSlots(t *testing.T) {
+	for _, format := range []Format{JSON, Gob} {
+		for _, n := range []int{0, 1, 5} {
+			s := &State{}
+			s.Update(func(tx *Tx) error {
+				tx.SetPotionSlots(n)
+				return nil
+			})
+
+			data, err := s.Snapshot().Encode(format)
+			assert.Nil(t, err)
+			snap, err := DecodeSnapshot(data, format)
+			assert.Nil(t, err)
+
+			r := &State{}
+			assert.Nil(t, r.Restore(snap))
+			assert.Equal(t, n, len(r.Potions()))
+			assert.Equal(t, n == 0, r.PotionSlotsFull())
+		}
+	}
+
+	// the default slots of the state never used them
+	r := &State{}
+	assert.Nil(t, r.Restore((&State{}).Snapshot()))
+	assert.Equal(t, DefaultPotionSlots, len(r.Potions()))
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	s := newSnapshotState()
+
+	snap := s.Snapshot()
+	snap.Version = SnapshotVersion + 1
+	assert.Equal(t, ErrSnapshotVersion, (&State{}).Restore(snap))
+
+	data, _ := snap.Encode(JSON)
+	_, err := DecodeSnapshot(data, JSON)
+	assert.Equal(t, ErrSnapshotVersion, err)
+
+	snap.Version = SnapshotVersion
+	snap.PotionSlots = 0
+	assert.Equal(t, ErrPotionSlot, (&State{}).Restore(snap))
+
+	_, err = snap.Encode(Format(-1))
+	assert.Equal(t, ErrSnapshotFormat, err)
+
+	// unknown card type, the state should not be changed
+	snap = s.Snapshot()
+	snap.Piles["hand"] = []cards.Data{{Type: "unknown", ID: "x"}}
+	snap.Num = 100
+	assert.Equal(t, cards.ErrUnknownType, s.Restore(snap))
+	assert.Equal(t, 7, s.Num())
+}
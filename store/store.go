@@ -1,9 +1,20 @@
 package store
 
 import (
+	"errors"
+	"math/rand"
+	"strconv"
 	"sync"
 
+	"github.com/rs/xid"
+	"github.com/sleep2death/hexcore/actors"
 	"github.com/sleep2death/hexcore/cards"
+	"github.com/sleep2death/hexcore/rng"
+)
+
+var (
+	// ErrStateNotExist -
+	ErrStateNotExist = errors.New("state doesn't exist")
 )
 
 // PileName -
@@ -20,109 +31,296 @@ const (
 	Discard
 	// Exhaust pile
 	Exhaust
+
+	// number of the piles, keep it at the end
+	pileCount
 )
 
+var pileNames = [pileCount]string{"deck", "draw", "hand", "discard", "exhaust"}
+
+func (n PileName) String() string {
+	if !n.valid() {
+		return "PileName(" + strconv.Itoa(int(n)) + ")"
+	}
+	return pileNames[n]
+}
+
+// ParsePileName returns the pile name of the string
+func ParsePileName(name string) (PileName, error) {
+	for i, n := range pileNames {
+		if n == name {
+			return PileName(i), nil
+		}
+	}
+	return 0, ErrInvalidPile
+}
+
+// defaultSeed is used when the state is not seeded explicitly,
+// it's the same as the default seed of math/rand
+const defaultSeed = 1
+
 // State - hold all status data of the player
 // it may access by different goroutines
-// so keep in mind about the concurrency safe
+// so keep in mind about the concurrency safe:
+// all the reads return copies of the data,
+// and all the mutations are made inside the transaction, see Update
 type State struct {
-	mu  sync.Mutex
+	mu  sync.RWMutex
 	num int
 
-	deck    *cards.Pile
-	draw    *cards.Pile
-	hand    *cards.Pile
-	discard *cards.Pile
-	exhaust *cards.Pile
+	// session id, which is assigned when the state is added into the store
+	session string
+
+	piles [pileCount]cards.Pile
+
+	player   actors.Player
+	monsters []actors.Monster
+	energy   int
+	gold     int
+	relics   []Relic
+	potions  []string
+	counters map[string]*Counter
+
+	// sequence of the card ids, see Tx.stamp
+	seq int
+
+	rng *rng.Source
+
+	// checkpoints for undo, see Mark
+	undo     []*Tx
+	revealed bool
+	// the changes can't be undone, see Seal
+	sealed bool
 }
 
 // Num of the state
 func (s *State) Num() int {
-	s.mu.Lock()
+	s.mu.RLock()
 	n := s.num
-	s.mu.Unlock()
+	s.mu.RUnlock()
 	return n
 }
 
 // SetNum of the state
 func (s *State) SetNum(i int) {
-	s.mu.Lock()
-	s.num = i
-	s.mu.Unlock()
+	s.Update(func(tx *Tx) error {
+		tx.SetNum(i)
+		return nil
+	})
 }
 
-// SetPile of the state
-func (s *State) SetPile(name PileName, pile *cards.Pile) {
-	s.mu.Lock()
-	switch name {
-	case Deck:
-		s.deck = pile
-	case Draw:
-		s.draw = pile
-	case Hand:
-		s.hand = pile
-	case Discard:
-		s.discard = pile
-	case Exhaust:
-		s.exhaust = pile
-	}
-	s.mu.Unlock()
+// Session id of the state, empty if the state is not added into the store
+func (s *State) Session() string {
+	s.mu.RLock()
+	id := s.session
+	s.mu.RUnlock()
+	return id
 }
 
-// GetPile of the state
-func (s *State) GetPile(name PileName) (pile *cards.Pile) {
-	s.mu.Lock()
-	switch name {
-	case Deck:
-		pile = s.deck
-	case Draw:
-		pile = s.draw
-	case Hand:
-		pile = s.hand
-	case Discard:
-		pile = s.discard
-	case Exhaust:
-		pile = s.exhaust
+// Player returns a copy of the player
+func (s *State) Player() actors.Player {
+	s.mu.RLock()
+	p := s.player
+	s.mu.RUnlock()
+	return p
+}
+
+// Monsters returns a copy of the monsters
+func (s *State) Monsters() []actors.Monster {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.monsters == nil {
+		return nil
 	}
-	s.mu.Unlock()
-	return pile
+
+	ms := make([]actors.Monster, len(s.monsters))
+	copy(ms, s.monsters)
+	return ms
 }
 
-// Shuffle the pile of the state
-func (s *State) Shuffle(name PileName) {
-	pile := s.GetPile(name)
+// Energy of the player
+func (s *State) Energy() int {
+	s.mu.RLock()
+	e := s.energy
+	s.mu.RUnlock()
+	return e
+}
 
-	s.mu.Lock()
-	pile.Shuffle()
-	s.mu.Unlock()
+// Gold of the player
+func (s *State) Gold() int {
+	s.mu.RLock()
+	g := s.gold
+	s.mu.RUnlock()
+	return g
 }
 
-// Draw the card from one pile to another
-func (s *State) Draw(from PileName, to PileName) (cards.Card, error) {
-	pFrom := s.GetPile(from)
-	pTo := s.GetPile(to)
+// Relics returns a copy of the relics owned by the player
+func (s *State) Relics() []Relic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneRelics(s.relics)
+}
 
+// Potions returns a copy of the potion slots,
+// it's nil if the slots are never used
+func (s *State) Potions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return clonePotions(s.potions)
+}
+
+// Seed the random source of the state
+func (s *State) Seed(seed int64) {
 	s.mu.Lock()
-	card, error := pTo.Draw(pFrom)
+	s.source().Seed(seed)
 	s.mu.Unlock()
-	return card, error
+}
+
+// SetPile of the state, the pile will be copied,
+// so modifying it later won't change the state
+func (s *State) SetPile(name PileName, pile cards.Pile) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.SetPile(name, pile)
+	})
+}
+
+// GetPile of the state, it returns a copy of the pile,
+// modifying it won't change the state.
+// The cards are shared with the state, they are read-only:
+// replace the card inside Update to change it, the piles read before keep the old one.
+func (s *State) GetPile(name PileName) cards.Pile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !name.valid() || s.piles[name] == nil {
+		return nil
+	}
+
+	return clonePile(s.piles[name])
+}
+
+// Shuffle the pile of the state
+func (s *State) Shuffle(name PileName) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.Shuffle(name)
+	})
+}
+
+// Draw the card from one pile to another
+func (s *State) Draw(from PileName, to PileName) (card cards.Card, err error) {
+	err = s.Update(func(tx *Tx) error {
+		card, err = tx.Draw(from, to)
+		return err
+	})
+	return card, err
 }
 
 // Pick the card from one pile to another
-func (s *State) Pick(id string, from PileName, to PileName) (cards.Card, error) {
-	pFrom := s.GetPile(from)
-	pTo := s.GetPile(to)
+func (s *State) Pick(id string, from PileName, to PileName) (card cards.Card, err error) {
+	err = s.Update(func(tx *Tx) error {
+		card, err = tx.Pick(id, from, to)
+		return err
+	})
+	return card, err
+}
 
+// Copy one pile to another
+func (s *State) Copy(from PileName, to PileName) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.Copy(from, to)
+	})
+}
+
+// Update runs fn inside a transaction of the state,
+// all the mutations made by the transaction will be committed atomically if fn returns nil,
+// otherwise they will be rolled back, and the error will be returned.
+// Don't call any method of the state inside fn, it will be deadlocked.
+func (s *State) Update(fn func(tx *Tx) error) error {
 	s.mu.Lock()
-	card, error := pTo.Pick(id, pFrom)
-	s.mu.Unlock()
-	return card, error
+	defer s.mu.Unlock()
+
+	tx := s.begin()
+	if err := fn(tx); err != nil {
+		s.rollback(tx)
+		return err
+	}
+
+	s.commit(tx)
+	return nil
 }
 
-// Copy one pile to another
-func (s *State) Copy(from PileName, to PileName) {
-	pFrom := s.GetPile(from)
-	s.SetPile(to, pFrom.Copy())
+// source returns the random source, lazy initialized
+// must be called with the lock held
+func (s *State) source() *rng.Source {
+	if s.rng == nil {
+		s.rng = rng.NewSource(defaultSeed)
+	}
+	return s.rng
+}
+
+func (s *State) begin() *Tx {
+	tx := &Tx{
+		num:      s.num,
+		player:   s.player,
+		energy:   s.energy,
+		gold:     s.gold,
+		relics:   cloneRelics(s.relics),
+		potions:  clonePotions(s.potions),
+		counters: cloneCounters(s.counters),
+		seq:      s.seq,
+		rngPos:   s.source().Position().Pos,
+		rand:     rand.New(s.source()),
+	}
+
+	if s.monsters != nil {
+		tx.monsters = make([]actors.Monster, len(s.monsters))
+		copy(tx.monsters, s.monsters)
+	}
+
+	for i := range s.piles {
+		if s.piles[i] != nil {
+			tx.piles[i] = clonePile(s.piles[i])
+		}
+	}
+
+	return tx
+}
+
+func (s *State) commit(tx *Tx) {
+	if tx.revealed || tx.rngPos != s.source().Position().Pos {
+		s.reveal()
+	} else if s.sealed {
+		s.undo = nil
+	}
+
+	s.num = tx.num
+	s.piles = tx.piles
+	s.player = tx.player
+	s.monsters = tx.monsters
+	s.energy = tx.energy
+	s.gold = tx.gold
+	s.relics = tx.relics
+	s.potions = tx.potions
+	s.counters = tx.counters
+	s.seq = tx.stamp()
+	tx.done = true
+}
+
+func (s *State) rollback(tx *Tx) {
+	s.rng.Seek(tx.rngPos)
+	tx.done = true
+}
+
+func (n PileName) valid() bool {
+	return n >= 0 && n < pileCount
+}
+
+// clonePile makes a new slice of the same cards
+func clonePile(p cards.Pile) cards.Pile {
+	c := make(cards.Pile, len(p))
+	copy(c, p)
+	return c
 }
 
 var store = &Store{}
@@ -134,9 +332,10 @@ func GetStore() *Store {
 
 // Store the state of the execution
 type Store struct {
-	mu     sync.Mutex
-	idx    int
-	states []*State
+	mu      sync.Mutex
+	idx     int
+	states  []*State
+	backend Backend
 }
 
 // Clear the states
@@ -146,8 +345,22 @@ func (s *Store) Clear() {
 	s.mu.Unlock()
 }
 
-// AddState of the store
+// SetBackend for persisting the states, nil to disable it
+func (s *Store) SetBackend(b Backend) {
+	s.mu.Lock()
+	s.backend = b
+	s.mu.Unlock()
+}
+
+// AddState of the store, a new session id will be assigned to the state,
+// if it doesn't have one
 func (s *Store) AddState(state *State) int {
+	state.mu.Lock()
+	if state.session == "" {
+		state.session = xid.New().String()
+	}
+	state.mu.Unlock()
+
 	s.mu.Lock()
 	s.states = append(s.states, state)
 	i := len(s.states) - 1
@@ -158,9 +371,74 @@ func (s *Store) AddState(state *State) int {
 // State of the store
 func (s *Store) State(idx int) *State {
 	s.mu.Lock()
-	// TODO: idx validation
+	defer s.mu.Unlock()
+
+	if idx < 0 || idx >= len(s.states) {
+		return nil
+	}
+	return s.states[idx]
+}
+
+// Checkpoint saves the snapshot of the state to the backend,
+// it should be called at the turn boundaries
+func (s *Store) Checkpoint(idx int) error {
+	s.mu.Lock()
+	if idx < 0 || idx >= len(s.states) {
+		s.mu.Unlock()
+		return ErrStateNotExist
+	}
+	b := s.backend
 	st := s.states[idx]
 	s.mu.Unlock()
 
-	return st
+	if b == nil {
+		return ErrNoBackend
+	}
+
+	data, err := st.Snapshot().Encode(Gob)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(st.Session(), data)
+}
+
+// Sessions saved in the backend
+func (s *Store) Sessions() ([]string, error) {
+	s.mu.Lock()
+	b := s.backend
+	s.mu.Unlock()
+
+	if b == nil {
+		return nil, ErrNoBackend
+	}
+	return b.List()
+}
+
+// Load the state of the session from its last checkpoint,
+// the returned state keeps the session id, so it can be started again
+func (s *Store) Load(session string) (*State, error) {
+	s.mu.Lock()
+	b := s.backend
+	s.mu.Unlock()
+
+	if b == nil {
+		return nil, ErrNoBackend
+	}
+
+	data, err := b.Get(session)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := DecodeSnapshot(data, Gob)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{session: session}
+	if err := state.Restore(snap); err != nil {
+		return nil, err
+	}
+	return state, nil
 }
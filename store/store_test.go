@@ -48,6 +48,8 @@ func TestStore(t *testing.T) {
 
 	s := GetStore().State(index)
 	assert.Equal(t, a, s)
+	assert.Nil(t, GetStore().State(-1))
+	assert.Nil(t, GetStore().State(1<<20))
 }
 
 func TestPiles(t *testing.T) {
@@ -59,28 +61,53 @@ func TestPiles(t *testing.T) {
 	}
 
 	s := &State{}
-	s.SetPile(Deck, &p)
+	s.SetPile(Deck, p)
 
 	h := make(cards.Pile, 0)
-	s.SetPile(Draw, &h)
+	s.SetPile(Draw, h)
 
-	assert.Equal(t, "0", (*s.GetPile(Deck))[0].ID())
-	assert.Equal(t, "9", (*s.GetPile(Deck))[9].ID())
-
-	deck := s.GetPile(Deck)
-	draw := s.GetPile(Draw)
+	assert.Equal(t, "0", s.GetPile(Deck)[0].ID())
+	assert.Equal(t, "9", s.GetPile(Deck)[9].ID())
 
 	s.Shuffle(Deck)
-	assert.Equal(t, "&[<card 1> <card 7> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8> <card 6>]", fmt.Sprint(deck))
+	assert.Equal(t, "[<card 1> <card 7> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8> <card 6>]", fmt.Sprint(s.GetPile(Deck)))
 
 	s.Draw(Deck, Draw)
-	assert.Equal(t, "&[<card 1> <card 7> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8>]", fmt.Sprint(deck))
-	assert.Equal(t, "&[<card 6>]", fmt.Sprint(draw))
+	assert.Equal(t, "[<card 1> <card 7> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8>]", fmt.Sprint(s.GetPile(Deck)))
+	assert.Equal(t, "[<card 6>]", fmt.Sprint(s.GetPile(Draw)))
 
 	s.Pick("7", Deck, Draw)
-	assert.Equal(t, "&[<card 1> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8>]", fmt.Sprint(deck))
-	assert.Equal(t, "&[<card 6> <card 7>]", fmt.Sprint(draw))
+	assert.Equal(t, "[<card 1> <card 4> <card 0> <card 9> <card 2> <card 3> <card 5> <card 8>]", fmt.Sprint(s.GetPile(Deck)))
+	assert.Equal(t, "[<card 6> <card 7>]", fmt.Sprint(s.GetPile(Draw)))
 
 	s.Copy(Draw, Hand)
-	assert.Equal(t, "&[<card copy:1 of <6>> <card copy:1 of <7>>]", fmt.Sprint(s.GetPile(Hand)))
+	assert.Equal(t, "[<card copy:1 of <6>> <card copy:1 of <7>>]", fmt.Sprint(s.GetPile(Hand)))
+
+	// the original pile and the returned pile are copies
+	p[0] = nil
+	deck := s.GetPile(Deck)
+	deck[0] = nil
+	assert.Equal(t, "1", s.GetPile(Deck)[0].ID())
+
+	// the cards are read-only, the replaced card isn't seen by the piles read before
+	before := s.GetPile(Deck)
+	s.Update(func(tx *Tx) error {
+		c := &cards.TestCard{}
+		c.SetID("x")
+		(*tx.Pile(Deck))[0] = c
+		return nil
+	})
+	assert.Equal(t, "1", before[0].ID())
+	assert.Equal(t, "x", s.GetPile(Deck)[0].ID())
+
+	// the invalid pile names are rejected
+	invalid := PileName(-1)
+	assert.Equal(t, ErrInvalidPile, s.Shuffle(invalid))
+	assert.Equal(t, ErrInvalidPile, s.Copy(Deck, invalid))
+	assert.Equal(t, ErrInvalidPile, s.SetPile(invalid, p))
+	_, err := s.Draw(invalid, Hand)
+	assert.Equal(t, ErrInvalidPile, err)
+	_, err = s.Pick("x", Deck, invalid)
+	assert.Equal(t, ErrInvalidPile, err)
+	assert.Nil(t, s.GetPile(invalid))
 }
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+
+	"github.com/sleep2death/hexcore/actors"
+	"github.com/sleep2death/hexcore/cards"
+)
+
+var (
+	// ErrTxClosed -
+	ErrTxClosed = errors.New("transaction is already committed or rolled back")
+	// ErrInvalidPile -
+	ErrInvalidPile = errors.New("invalid pile name")
+	// ErrActorNotExist -
+	ErrActorNotExist = errors.New("actor doesn't exist")
+)
+
+// Tx is a transaction of the state, see State.Update.
+// It works on the copies of the state data,
+// so nothing will be changed until it's committed.
+// Notice that only the piles are copied, not the cards inside them,
+// so replace the card instead of modifying it, if you want it can be rolled back.
+type Tx struct {
+	num   int
+	piles [pileCount]cards.Pile
+
+	player   actors.Player
+	monsters []actors.Monster
+	energy   int
+	gold     int
+	relics   []Relic
+	potions  []string
+	counters map[string]*Counter
+	seq      int
+
+	rngPos uint64
+	rand   *rand.Rand
+
+	// hidden information is revealed by the transaction
+	revealed bool
+
+	done bool
+}
+
+// Num of the transaction
+func (tx *Tx) Num() int {
+	return tx.num
+}
+
+// SetNum of the transaction
+func (tx *Tx) SetNum(i int) {
+	tx.num = i
+}
+
+// Player of the transaction, it can be modified directly
+func (tx *Tx) Player() *actors.Player {
+	return &tx.player
+}
+
+// Monsters of the transaction, the elements can be modified directly
+func (tx *Tx) Monsters() []actors.Monster {
+	return tx.monsters
+}
+
+// SetMonsters of the transaction, the slice will be copied
+func (tx *Tx) SetMonsters(ms []actors.Monster) {
+	tx.monsters = make([]actors.Monster, len(ms))
+	copy(tx.monsters, ms)
+}
+
+// Monster by id, it can be modified directly
+func (tx *Tx) Monster(id string) (*actors.Monster, error) {
+	for i := range tx.monsters {
+		if tx.monsters[i].ID() == id {
+			return &tx.monsters[i], nil
+		}
+	}
+	return nil, ErrActorNotExist
+}
+
+// Energy of the player
+func (tx *Tx) Energy() int {
+	return tx.energy
+}
+
+// SetEnergy of the player
+func (tx *Tx) SetEnergy(e int) {
+	tx.energy = e
+}
+
+// Gold of the player
+func (tx *Tx) Gold() int {
+	return tx.gold
+}
+
+// SetGold of the player
+func (tx *Tx) SetGold(g int) {
+	tx.gold = g
+}
+
+// Rand returns the random generator of the state,
+// the numbers drawn from it will be rolled back with the transaction too
+func (tx *Tx) Rand() *rand.Rand {
+	return tx.rand
+}
+
+// Pile returns the working pile of the transaction, it can be modified directly,
+// but it will be invalid after the transaction is finished.
+// It panics with an invalid name, check the names from the input by ParsePileName,
+// or use the other methods of the transaction, which return ErrInvalidPile.
+func (tx *Tx) Pile(name PileName) *cards.Pile {
+	if tx.done {
+		panic(ErrTxClosed)
+	}
+
+	if !name.valid() {
+		panic(ErrInvalidPile)
+	}
+
+	if tx.piles[name] == nil {
+		tx.piles[name] = make(cards.Pile, 0)
+	}
+
+	return &tx.piles[name]
+}
+
+// SetPile of the transaction, the pile will be copied
+func (tx *Tx) SetPile(name PileName, pile cards.Pile) error {
+	if !name.valid() {
+		return ErrInvalidPile
+	}
+	*tx.Pile(name) = clonePile(pile)
+	return nil
+}
+
+// Shuffle the pile
+func (tx *Tx) Shuffle(name PileName) error {
+	if !name.valid() {
+		return ErrInvalidPile
+	}
+	tx.Pile(name).Shuffle(tx.rand)
+	return nil
+}
+
+// Reveal marks that hidden information is revealed by the transaction,
+// so the player inputs before it can't be undone.
+// Drawing from the draw pile and using the random generator are marked automatically.
+func (tx *Tx) Reveal() {
+	tx.revealed = true
+}
+
+// Draw the card from one pile to another
+func (tx *Tx) Draw(from PileName, to PileName) (cards.Card, error) {
+	if !from.valid() || !to.valid() {
+		return nil, ErrInvalidPile
+	}
+	card, err := tx.Pile(to).Draw(tx.Pile(from))
+	if err == nil && from == Draw {
+		tx.Reveal()
+	}
+	return card, err
+}
+
+// Pick the card from one pile to another
+func (tx *Tx) Pick(id string, from PileName, to PileName) (cards.Card, error) {
+	if !from.valid() || !to.valid() {
+		return nil, ErrInvalidPile
+	}
+	return tx.Pile(to).Pick(id, tx.Pile(from))
+}
+
+// Copy one pile to another
+func (tx *Tx) Copy(from PileName, to PileName) error {
+	if !from.valid() || !to.valid() {
+		return ErrInvalidPile
+	}
+	*tx.Pile(to) = *tx.Pile(from).Copy()
+	return nil
+}
+
+// stamp the new cards without id with the next ids of the sequence,
+// so the ids are the same when the run is replayed. It returns the sequence
+func (tx *Tx) stamp() int {
+	for _, p := range tx.piles {
+		for _, c := range p {
+			if c.ID() == "" {
+				tx.seq++
+				c.SetID(strconv.Itoa(tx.seq))
+			}
+		}
+	}
+	return tx.seq
+}
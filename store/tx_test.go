@@ -0,0 +1,146 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/sleep2death/hexcore/cards"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPile(n int) cards.Pile {
+	p := make(cards.Pile, 0, n)
+	for i := 0; i < n; i++ {
+		card := &cards.TestCard{}
+		card.SetID(strconv.Itoa(i))
+		p = append(p, card)
+	}
+	return p
+}
+
+func TestUpdateCommit(t *testing.T) {
+	s := &State{}
+	s.SetPile(Draw, newTestPile(5))
+
+	err := s.Update(func(tx *Tx) error {
+		tx.SetNum(3)
+		for i := 0; i < 3; i++ {
+			if _, err := tx.Draw(Draw, Hand); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, s.Num())
+	assert.Equal(t, "[<card 0> <card 1>]", fmt.Sprint(s.GetPile(Draw)))
+	assert.Equal(t, "[<card 4> <card 3> <card 2>]", fmt.Sprint(s.GetPile(Hand)))
+}
+
+func TestUpdateRollback(t *testing.T) {
+	s := &State{}
+	s.SetNum(1)
+	s.SetPile(Draw, newTestPile(2))
+
+	// not enough cards to draw, nothing should be changed
+	err := s.Update(func(tx *Tx) error {
+		tx.SetNum(2)
+		tx.Shuffle(Draw)
+		for i := 0; i < 3; i++ {
+			if _, err := tx.Draw(Draw, Hand); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	assert.Equal(t, cards.ErrNotEnoughCards, err)
+	assert.Equal(t, 1, s.Num())
+	assert.Equal(t, "[<card 0> <card 1>]", fmt.Sprint(s.GetPile(Draw)))
+	assert.Equal(t, 0, len(s.GetPile(Hand)))
+
+	// random numbers are rolled back too
+	var a, b int
+	errRollback := errors.New("rollback")
+	s.Update(func(tx *Tx) error {
+		a = tx.Rand().Int()
+		return errRollback
+	})
+	s.Update(func(tx *Tx) error {
+		b = tx.Rand().Int()
+		return nil
+	})
+	assert.Equal(t, a, b)
+}
+
+func TestUpdateIDs(t *testing.T) {
+	add := func(s *State, fail bool) error {
+		return s.Update(func(tx *Tx) error {
+			*tx.Pile(Deck) = append(*tx.Pile(Deck), &cards.TestCard{}, &cards.TestCard{})
+			if fail {
+				return errors.New("rollback")
+			}
+			return nil
+		})
+	}
+
+	// the new cards are given the next ids of the sequence, the rolled back ones don't count
+	s := &State{}
+	assert.Nil(t, add(s, false))
+	assert.NotNil(t, add(s, true))
+	s.Mark()
+	assert.Nil(t, add(s, false))
+	assert.Equal(t, "[<card 1> <card 2> <card 3> <card 4>]", fmt.Sprint(s.GetPile(Deck)))
+
+	// so does undo, and the snapshot keeps the sequence
+	assert.Nil(t, s.Undo())
+	r := &State{}
+	assert.Nil(t, r.Restore(s.Snapshot()))
+	for _, st := range []*State{s, r} {
+		assert.Nil(t, add(st, false))
+		assert.Equal(t, "[<card 1> <card 2> <card 3> <card 4>]", fmt.Sprint(st.GetPile(Deck)))
+	}
+}
+
+func TestUpdateConcurrency(t *testing.T) {
+	s := &State{}
+	s.SetPile(Draw, newTestPile(100))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				s.Update(func(tx *Tx) error {
+					tx.SetNum(tx.Num() + 1)
+					tx.Shuffle(Draw)
+					_, err := tx.Draw(Draw, Hand)
+					return err
+				})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			last := 0
+			for j := 0; j < 10; j++ {
+				// reads never see a half finished transaction
+				hand := s.GetPile(Hand)
+				assert.True(t, len(hand) >= last && len(hand) <= 100)
+				last = len(hand)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 100, s.Num())
+	assert.Equal(t, 0, len(s.GetPile(Draw)))
+	assert.Equal(t, 100, len(s.GetPile(Hand)))
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNothingToUndo -
+	ErrNothingToUndo = errors.New("nothing to undo in this turn")
+	// ErrUndoRevealed -
+	ErrUndoRevealed = errors.New("can't undo, hidden information has been revealed")
+)
+
+// Mark a checkpoint of the state before executing a player input,
+// so the input can be reverted by Undo later in the same turn
+func (s *State) Mark() {
+	s.mu.Lock()
+	s.undo = append(s.undo, s.begin())
+	s.sealed = false
+	s.mu.Unlock()
+}
+
+// Seal the checkpoints before executing a player input which can't be undone,
+// e.g. using a potion, they are dropped once the state is changed by the input,
+// so undo won't revert the input together with the undoable one before it
+func (s *State) Seal() {
+	s.mu.Lock()
+	s.sealed = true
+	s.mu.Unlock()
+}
+
+// Unmark drops the most recent checkpoint without reverting the state,
+// e.g. the input is rejected before it changed anything
+func (s *State) Unmark() {
+	s.mu.Lock()
+	if len(s.undo) > 0 {
+		s.undo = s.undo[:len(s.undo)-1]
+	}
+	s.mu.Unlock()
+}
+
+// Undo reverts the state to the most recent checkpoint.
+// It fails when there is no checkpoint in this turn,
+// or the checkpoints are dropped because hidden information (draw, random outcome) is revealed
+func (s *State) Undo() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.undoable(); err != nil {
+		return err
+	}
+
+	cp := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+
+	s.num = cp.num
+	s.piles = cp.piles
+	s.player = cp.player
+	s.monsters = cp.monsters
+	s.energy = cp.energy
+	s.gold = cp.gold
+	s.relics = cp.relics
+	s.potions = cp.potions
+	s.counters = cp.counters
+	s.seq = cp.seq
+	s.source().Seek(cp.rngPos)
+	return nil
+}
+
+// CanUndo returns nil if the state can be undone, otherwise the reason
+func (s *State) CanUndo() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.undoable()
+}
+
+// EndTurn drops all the checkpoints of the turn
+func (s *State) EndTurn() {
+	s.mu.Lock()
+	s.undo = nil
+	s.revealed = false
+	s.sealed = false
+	s.mu.Unlock()
+}
+
+// reveal drops all the checkpoints, must be called with the lock held
+func (s *State) reveal() {
+	if len(s.undo) > 0 {
+		s.undo = nil
+		s.revealed = true
+	}
+}
+
+// must be called with the lock held
+func (s *State) undoable() error {
+	if len(s.undo) == 0 {
+		if s.revealed {
+			return ErrUndoRevealed
+		}
+		return ErrNothingToUndo
+	}
+	return nil
+}
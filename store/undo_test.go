@@ -0,0 +1,91 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo(t *testing.T) {
+	s := &State{}
+	assert.Equal(t, ErrNothingToUndo, s.Undo())
+
+	s.SetPile(Draw, newTestPile(5))
+	s.SetPile(Hand, newTestPile(3))
+	s.Update(func(tx *Tx) error {
+		tx.SetEnergy(3)
+		return nil
+	})
+
+	// play two cards
+	for _, id := range []string{"0", "1"} {
+		s.Mark()
+		s.Update(func(tx *Tx) error {
+			tx.SetEnergy(tx.Energy() - 1)
+			c, _ := tx.Counter("mantra", Value)
+			c.Value += 2
+			_, err := tx.Pick(id, Hand, Discard)
+			return err
+		})
+	}
+	assert.Equal(t, 1, s.Energy())
+	assert.Nil(t, s.CanUndo())
+
+	assert.Nil(t, s.Undo())
+	assert.Equal(t, 2, s.Energy())
+	c, _ := s.Counter("mantra")
+	assert.Equal(t, 2, c.Value)
+	assert.Equal(t, "[<card 0>]", fmt.Sprint(s.GetPile(Discard)))
+	assert.Equal(t, "[<card 1> <card 2>]", fmt.Sprint(s.GetPile(Hand)))
+
+	// the checkpoint of a rejected input is dropped
+	s.Mark()
+	s.Unmark()
+
+	assert.Nil(t, s.Undo())
+	assert.Equal(t, 3, s.Energy())
+	assert.Equal(t, 0, len(s.GetPile(Discard)))
+	assert.Equal(t, ErrNothingToUndo, s.Undo())
+	s.Unmark()
+
+	// drawing a card reveals hidden information
+	s.Mark()
+	s.Update(func(tx *Tx) error {
+		_, err := tx.Draw(Draw, Hand)
+		return err
+	})
+	assert.Equal(t, ErrUndoRevealed, s.Undo())
+
+	// new input after that can be undone
+	s.Mark()
+	s.SetNum(10)
+	assert.Nil(t, s.Undo())
+	assert.Equal(t, 0, s.Num())
+	assert.Equal(t, ErrUndoRevealed, s.Undo())
+
+	// random outcome reveals hidden information too
+	s.EndTurn()
+	s.Mark()
+	s.Shuffle(Hand)
+	assert.Equal(t, ErrUndoRevealed, s.CanUndo())
+
+	// rolled back transaction reveals nothing
+	s.EndTurn()
+	s.Mark()
+	s.Pick("unknown", Draw, Hand)
+	assert.Nil(t, s.CanUndo())
+
+	// the sealed checkpoints are dropped by the next change, until the next mark
+	s.Seal()
+	assert.Nil(t, s.CanUndo())
+	s.SetNum(20)
+	assert.Equal(t, ErrNothingToUndo, s.CanUndo())
+	s.Mark()
+	s.SetNum(21)
+	assert.Nil(t, s.Undo())
+	assert.Equal(t, 20, s.Num())
+
+	s.EndTurn()
+	assert.Equal(t, ErrNothingToUndo, s.Undo())
+}